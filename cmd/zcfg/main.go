@@ -0,0 +1,16 @@
+// Command zcfg validates, converts, prints and diffs config files and generates
+// Go config structs from sample files.
+//
+// To validate against Go config types, build a copy of this main that calls
+// cli.Register for each type before cli.Run.
+package main
+
+import (
+	"os"
+
+	"github.com/meta-apex/zenith/zcfg/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
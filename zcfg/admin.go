@@ -0,0 +1,269 @@
+package zcfg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HandlerOption represents admin handler options
+type HandlerOption struct {
+	Authorize   func(r *http.Request) (string, error) // Returns the caller identity, or an error to reject the request
+	Audit       func(entry AuditEntry)                // Receives an entry for each patch, default logs with the config logger
+	ReadOnly    bool                                  // Whether to reject patches even if the config is updatable
+	MaxBodySize int64                                 // Maximum patch size in bytes
+}
+
+// NewHandlerOption creates a new HandlerOption with default values
+func NewHandlerOption() *HandlerOption {
+	return &HandlerOption{
+		Authorize:   nil,
+		Audit:       nil,
+		ReadOnly:    false,
+		MaxBodySize: 1 << 20,
+	}
+}
+
+// WithAuthorize sets the function that authenticates and authorizes admin requests
+func WithAuthorize(authorize func(r *http.Request) (string, error)) func(*HandlerOption) {
+	return func(o *HandlerOption) {
+		o.Authorize = authorize
+	}
+}
+
+// WithAudit sets the function that receives audit entries for patches
+func WithAudit(audit func(entry AuditEntry)) func(*HandlerOption) {
+	return func(o *HandlerOption) {
+		o.Audit = audit
+	}
+}
+
+// WithReadOnly sets whether the handler rejects patches
+func WithReadOnly(readOnly bool) func(*HandlerOption) {
+	return func(o *HandlerOption) {
+		o.ReadOnly = readOnly
+	}
+}
+
+// WithMaxBodySize sets the maximum patch size in bytes
+func WithMaxBodySize(size int64) func(*HandlerOption) {
+	return func(o *HandlerOption) {
+		o.MaxBodySize = size
+	}
+}
+
+// AuditEntry records a configuration change made through the admin handler
+type AuditEntry struct {
+	Time    time.Time `json:"time"`            // Time of the request
+	User    string    `json:"user,omitempty"`  // Identity returned by Authorize
+	Remote  string    `json:"remote"`          // Remote address of the request
+	Method  string    `json:"method"`          // HTTP method
+	Path    string    `json:"path"`            // Request path
+	Changes []Change  `json:"changes"`         // Applied changes, secret values are masked
+	Error   string    `json:"error,omitempty"` // Error if the patch was rejected
+}
+
+// adminHandler serves the admin endpoints of a config
+type adminHandler struct {
+	config  *Config
+	option  *HandlerOption
+	mux     *http.ServeMux
+	patchMu sync.Mutex
+}
+
+// NewHandler creates an http.Handler to view and patch a live config.
+// Mount it under a prefix with http.StripPrefix. Endpoints:
+//
+//	GET   /        effective config with secret fields masked
+//	PATCH /        apply a JSON Merge Patch with Content-Type application/merge-patch+json or
+//	               application/json, or a JSON Patch with application/json-patch+json
+//	               (requires Updatable, other content types are rejected with 415)
+//	GET   /schema  schema of the config type
+//	GET   /status  hot reload status
+//	GET   /history retained snapshots with changes, secret values masked
+func NewHandler(c *Config, opts ...func(*HandlerOption)) http.Handler {
+	option := NewHandlerOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	h := &adminHandler{
+		config: c,
+		option: option,
+		mux:    http.NewServeMux(),
+	}
+	h.mux.HandleFunc("GET /{$}", h.handleGet)
+	h.mux.HandleFunc("PATCH /{$}", h.handlePatch)
+	h.mux.HandleFunc("GET /schema", h.handleSchema)
+	h.mux.HandleFunc("GET /status", h.handleStatus)
+	h.mux.HandleFunc("GET /history", h.handleHistory)
+
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.option.Authorize != nil {
+		user, err := h.option.Authorize(r)
+		if err != nil {
+			writeJSONError(w, http.StatusUnauthorized, err)
+			return
+		}
+		r = r.WithContext(withAdminUser(r.Context(), user))
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleGet serves the effective config
+func (h *adminHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config.Redacted())
+}
+
+// handleSchema serves the config schema
+func (h *adminHandler) handleSchema(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config.Schema())
+}
+
+// handleStatus serves the reload status
+func (h *adminHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config.ReloadStatus())
+}
+
+// handleHistory serves the config history
+func (h *adminHandler) handleHistory(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.config.History())
+}
+
+// handlePatch applies a JSON Merge Patch or JSON Patch and records an audit entry
+func (h *adminHandler) handlePatch(w http.ResponseWriter, r *http.Request) {
+	if h.option.ReadOnly || !h.config.option.Updatable {
+		writeJSONError(w, http.StatusForbidden, errors.New("config is not updatable"))
+		return
+	}
+
+	jsonPatch, err := isJSONPatch(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeJSONError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.option.MaxBodySize))
+	if err != nil {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+
+	if !json.Valid(body) {
+		writeJSONError(w, http.StatusBadRequest, errors.New("invalid JSON body"))
+		return
+	}
+
+	// Serialize patches so each audit entry only contains its own changes
+	h.patchMu.Lock()
+	before := h.config.snapshotView()
+	if jsonPatch {
+		err = h.config.ApplyJSONPatch(body)
+	} else {
+		err = h.config.ApplyMergePatch(body)
+	}
+	after := h.config.snapshotView()
+	h.patchMu.Unlock()
+
+	entry := AuditEntry{
+		Time:    time.Now(),
+		User:    adminUser(r.Context()),
+		Remote:  r.RemoteAddr,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Changes: Diff(before, after),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	h.audit(entry)
+
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.config.Redacted())
+}
+
+// isJSONPatch reports whether contentType selects a JSON Patch, otherwise the body is a JSON Merge Patch.
+// A missing content type is a merge patch, other media types are rejected.
+func isJSONPatch(contentType string) (bool, error) {
+	if contentType == "" {
+		return false, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, fmt.Errorf("invalid Content-Type %s: %w", contentType, err)
+	}
+	switch mediaType {
+	case "application/json-patch+json":
+		return true, nil
+	case "application/merge-patch+json", "application/json":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported Content-Type %s, expected application/merge-patch+json or application/json-patch+json", mediaType)
+	}
+}
+
+// audit passes entry to the audit function or logs it
+func (h *adminHandler) audit(entry AuditEntry) {
+	if h.option.Audit != nil {
+		h.option.Audit(entry)
+		return
+	}
+
+	changes := make([]string, len(entry.Changes))
+	for i, change := range entry.Changes {
+		changes[i] = change.String()
+	}
+	logEntry := h.config.option.logger().Info()
+	if entry.Error != "" {
+		logEntry = h.config.option.logger().Warn().Str("error", entry.Error)
+	}
+	logEntry.Str("user", entry.User).Str("remote", entry.Remote).Strs("changes", changes).Msg("config patched via admin handler")
+}
+
+// adminUserKey is the context key for the identity returned by Authorize
+type adminUserKey struct{}
+
+// withAdminUser returns a context carrying the admin user identity
+func withAdminUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, adminUserKey{}, user)
+}
+
+// adminUser returns the admin user identity from the context
+func adminUser(ctx context.Context) string {
+	user, _ := ctx.Value(adminUserKey{}).(string)
+	return user
+}
+
+// snapshotView returns the current config as a map with secrets replaced by digests
+func (c *Config) snapshotView() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.digestView()
+}
+
+// writeJSON writes v as JSON response with status
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeJSONError writes err as JSON error response with status
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": fmt.Sprintf("%v", err)})
+}
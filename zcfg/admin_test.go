@@ -0,0 +1,118 @@
+package zcfg
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type adminConfig struct {
+	Port  int    `meta:"port"`
+	Level string `meta:"level,default=info"`
+	Token string `meta:"token,secret,optional"`
+}
+
+// serveAdmin sends a request to handler and returns the response recorder
+func serveAdmin(handler http.Handler, method, path, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminGetMasksSecrets(t *testing.T) {
+	config, _ := newTestConfig[adminConfig](t, map[string]any{"port": 80, "token": "s3cret"})
+	rec := serveAdmin(NewHandler(config), http.MethodGet, "/", "", "")
+
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "s3cret") {
+		t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+	var m map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil || m["token"] != RedactedValue || m["port"] != float64(80) {
+		t.Fatalf("unexpected body %s", rec.Body.String())
+	}
+}
+
+func TestAdminPatch(t *testing.T) {
+	config, target := newTestConfig[adminConfig](t, map[string]any{"port": 80})
+	var entries []AuditEntry
+	handler := NewHandler(config, WithAudit(func(entry AuditEntry) { entries = append(entries, entry) }))
+
+	rec := serveAdmin(handler, http.MethodPatch, "/", "application/merge-patch+json; charset=utf-8", `{"port":81,"level":null}`)
+	if rec.Code != http.StatusOK || target.Port != 81 || target.Level != "info" {
+		t.Fatalf("merge patch failed %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serveAdmin(handler, http.MethodPatch, "/", "application/json-patch+json; charset=utf-8", `[{"op":"replace","path":"/port","value":82}]`)
+	if rec.Code != http.StatusOK || target.Port != 82 {
+		t.Fatalf("JSON patch failed %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serveAdmin(handler, http.MethodPatch, "/", "text/plain", `{"port":83}`)
+	if rec.Code != http.StatusUnsupportedMediaType || target.Port != 82 {
+		t.Fatalf("unsupported content type answered %d", rec.Code)
+	}
+
+	rec = serveAdmin(handler, http.MethodPatch, "/", "application/json", `{"port":"x"}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("invalid patch answered %d", rec.Code)
+	}
+
+	if len(entries) != 3 || len(entries[1].Changes) != 1 || entries[1].Changes[0].Path != "port" || entries[2].Error == "" {
+		t.Fatalf("unexpected audit entries %+v", entries)
+	}
+}
+
+func TestAdminPatchNotUpdatable(t *testing.T) {
+	config, target := newTestConfig[adminConfig](t, map[string]any{"port": 80}, WithUpdatable(false))
+	rec := serveAdmin(NewHandler(config), http.MethodPatch, "/", "application/json", `{"port":81}`)
+	if rec.Code != http.StatusForbidden || target.Port != 80 {
+		t.Fatalf("patch of read-only config answered %d", rec.Code)
+	}
+}
+
+func TestAdminAuthorize(t *testing.T) {
+	config, _ := newTestConfig[adminConfig](t, map[string]any{"port": 80})
+	var users []string
+	handler := NewHandler(config,
+		WithAuthorize(func(r *http.Request) (string, error) {
+			if r.Header.Get("Authorization") != "Bearer admin" {
+				return "", errors.New("unauthorized")
+			}
+			return "admin", nil
+		}),
+		WithAudit(func(entry AuditEntry) { users = append(users, entry.User) }))
+
+	if rec := serveAdmin(handler, http.MethodGet, "/", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthorized request answered %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"port":81}`))
+	req.Header.Set("Authorization", "Bearer admin")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || len(users) != 1 || users[0] != "admin" {
+		t.Fatalf("authorized patch answered %d, audit users %v", rec.Code, users)
+	}
+}
+
+func TestAdminHistory(t *testing.T) {
+	config, _ := newTestConfig[adminConfig](t, map[string]any{"port": 80, "token": "a"})
+	handler := NewHandler(config)
+	serveAdmin(handler, http.MethodPatch, "/", "application/json", `{"token":"hunter2"}`)
+
+	rec := serveAdmin(handler, http.MethodGet, "/history", "", "")
+	var history []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(history) != 2 || history[1]["version"] != float64(2) || strings.Contains(rec.Body.String(), "hunter2") {
+		t.Fatalf("unexpected history %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,461 @@
+// Package cli implements the zcfg command line tool.
+//
+// The tool validates, converts, prints and diffs config files and generates
+// Go config structs from sample files. Validation
+// against Go config types works without plugins: a service provides its own
+// small main that registers its types and calls Run.
+//
+//	func main() {
+//		cli.Register[config.App]("app")
+//		os.Exit(cli.Run(os.Args[1:], os.Stdout, os.Stderr))
+//	}
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/meta-apex/zenith/zcfg"
+)
+
+// registeredType validates merged raw config maps and finds their secret keys
+type registeredType struct {
+	validate   func(m map[string]any, strict bool) error
+	secretKeys func(m map[string]any) []string
+}
+
+// Registered config types for --type
+var (
+	typesMu sync.RWMutex
+	types   = make(map[string]registeredType)
+)
+
+// Register registers config type T under name, so `validate --type name` checks files
+// with the same mapping, tag rules and hooks as zcfg.Load, and `print` and `diff`
+// with --type name also mask the fields of T tagged secret or of type zcfg.Secret
+func Register[T any](name string, opts ...func(*zcfg.Option)) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	types[name] = registeredType{
+		validate: func(m map[string]any, strict bool) error {
+			loadOpts := append([]func(*zcfg.Option){}, opts...)
+			loadOpts = append(loadOpts, zcfg.WithRegistry(zcfg.NewRegistry()), zcfg.WithStrict(strict))
+			_, err := zcfg.LoadFromMap[T](m, loadOpts...)
+			return err
+		},
+		secretKeys: func(m map[string]any) []string {
+			return zcfg.SecretKeys[T](m, opts...)
+		},
+	}
+}
+
+// lookupType returns the type registered under name
+func lookupType(name string) (registeredType, error) {
+	typesMu.RLock()
+	t, exists := types[name]
+	typesMu.RUnlock()
+	if !exists {
+		return registeredType{}, fmt.Errorf("%w: unknown type %q, registered types: %s", errUsage, name, strings.Join(registeredNames(), ", "))
+	}
+	return t, nil
+}
+
+// typeFlagUsage returns the usage of a -type flag
+func typeFlagUsage(purpose string) string {
+	return "registered config type " + purpose + " (" + strings.Join(registeredNames(), ", ") + ")"
+}
+
+// registeredNames returns the sorted names of registered types
+func registeredNames() []string {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Exit codes
+const (
+	ExitOK    = 0 // Success
+	ExitFail  = 1 // Validation failed, or diff found differences
+	ExitUsage = 2 // Invalid arguments or unreadable input
+)
+
+// errUsage marks errors caused by invalid arguments
+var errUsage = errors.New("usage error")
+
+const usage = `Usage: zcfg <command> [flags] <files...>
+
+Commands:
+  validate  Validate config files against a registered type or schema file
+  convert   Convert a config file between JSON, YAML and TOML
+  print     Print the effective merged config with env expanded and secrets redacted
+  diff      Show semantic differences between two config files
+  gen       Generate Go config structs from a sample config file
+
+Multiple files are merged in order, later files override earlier ones.
+Run 'zcfg <command> -h' for command flags.
+`
+
+// Run runs the tool with args (without the program name) and returns the exit code
+func Run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return ExitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "validate":
+		err = runValidate(args[1:], stdout, stderr)
+	case "convert":
+		err = runConvert(args[1:], stdout, stderr)
+	case "print":
+		err = runPrint(args[1:], stdout, stderr)
+	case "diff":
+		err = runDiff(args[1:], stdout, stderr)
+	case "gen":
+		err = runGen(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "zcfg: unknown command %q\n\n%s", args[0], usage)
+		return ExitUsage
+	}
+
+	var failed *failError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &failed):
+		if failed.err != nil {
+			fmt.Fprintf(stderr, "zcfg: %v\n", failed.err)
+		}
+		return ExitFail
+	default:
+		fmt.Fprintf(stderr, "zcfg: %v\n", err)
+		return ExitUsage
+	}
+}
+
+// failError marks a completed command whose result is negative
+type failError struct {
+	err error
+}
+
+// Error implements error
+func (e *failError) Error() string {
+	if e.err == nil {
+		return "failed"
+	}
+	return e.err.Error()
+}
+
+// newFlagSet creates a flag set for a command writing its usage to stderr
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: zcfg %s [flags] %s\n\nFlags:\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// loadFiles parses files and merges them in order
+func loadFiles(files []string) (map[string]any, error) {
+	merged := make(map[string]any)
+	for _, file := range files {
+		m, err := zcfg.ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		merged = zcfg.MergeMaps(merged, m)
+	}
+	return merged, nil
+}
+
+// parseFormat parses a format flag value
+func parseFormat(name string) (zcfg.Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return zcfg.FormatJSON, nil
+	case "yaml", "yml":
+		return zcfg.FormatYAML, nil
+	case "toml":
+		return zcfg.FormatTOML, nil
+	default:
+		return "", fmt.Errorf("%w: unsupported format %q", errUsage, name)
+	}
+}
+
+// runValidate implements the validate command
+func runValidate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("validate", "<files...>", stderr)
+	typeName := fs.String("type", "", typeFlagUsage("to validate against"))
+	schemaFile := fs.String("schema", "", "schema file describing keys, types and tag rules")
+	strict := fs.Bool("strict", false, "reject unknown keys")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: no config files given", errUsage)
+	}
+	if (*typeName == "") == (*schemaFile == "") {
+		return fmt.Errorf("%w: exactly one of -type or -schema is required", errUsage)
+	}
+
+	m, err := loadFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if *typeName != "" {
+		t, lookupErr := lookupType(*typeName)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		err = t.validate(m, *strict)
+	} else {
+		schema, parseErr := zcfg.ParseFile(*schemaFile)
+		if parseErr != nil {
+			return parseErr
+		}
+		err = zcfg.ValidateSchema(m, schema, zcfg.WithStrict(*strict))
+	}
+	if err != nil {
+		return &failError{err: err}
+	}
+
+	fmt.Fprintf(stdout, "%s: ok\n", strings.Join(fs.Args(), ", "))
+	return nil
+}
+
+// runConvert implements the convert command
+func runConvert(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("convert", "<file>", stderr)
+	to := fs.String("to", "", "output format: json, yaml or toml (default: from -o extension)")
+	output := fs.String("o", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("%w: convert takes exactly one file", errUsage)
+	}
+
+	var format zcfg.Format
+	var err error
+	switch {
+	case *to != "":
+		format, err = parseFormat(*to)
+	case *output != "":
+		format, err = zcfg.FormatFromExt(*output)
+	default:
+		err = fmt.Errorf("%w: -to or -o is required", errUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := zcfg.ParseFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(m, format, *output, stdout)
+}
+
+// runPrint implements the print command
+func runPrint(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("print", "<files...>", stderr)
+	formatName := fs.String("format", "", "output format: json, yaml or toml (default: format of the first file)")
+	noEnv := fs.Bool("no-env", false, "do not expand ${VAR} references")
+	showSecrets := fs.Bool("show-secrets", false, "do not redact sensitive values")
+	typeName := fs.String("type", "", typeFlagUsage("whose secret fields are redacted"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: no config files given", errUsage)
+	}
+
+	format, err := zcfg.FormatFromExt(fs.Arg(0))
+	if *formatName != "" {
+		format, err = parseFormat(*formatName)
+	}
+	if err != nil {
+		return err
+	}
+
+	m, err := loadFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+	if !*noEnv {
+		if m, err = zcfg.ExpandEnv(m); err != nil {
+			return err
+		}
+	}
+	if !*showSecrets {
+		secretKeys, err := typeSecretKeys(*typeName, m)
+		if err != nil {
+			return err
+		}
+		m = zcfg.RedactMap(m, secretKeys...)
+	}
+
+	return writeOutput(m, format, "", stdout)
+}
+
+// typeSecretKeys returns the secret keys in m of the type registered under name, none without a name
+func typeSecretKeys(name string, m map[string]any) ([]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	t, err := lookupType(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.secretKeys(m), nil
+}
+
+// runDiff implements the diff command
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("diff", "<old> <new>", stderr)
+	expandEnv := fs.Bool("env", false, "expand ${VAR} references before comparing")
+	showSecrets := fs.Bool("show-secrets", false, "do not redact sensitive values")
+	typeName := fs.String("type", "", typeFlagUsage("whose secret fields are redacted"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return fmt.Errorf("%w: diff takes exactly two files", errUsage)
+	}
+
+	maps := make([]map[string]any, 2)
+	for i, file := range fs.Args() {
+		m, err := zcfg.ParseFile(file)
+		if err != nil {
+			return err
+		}
+		if *expandEnv {
+			if m, err = zcfg.ExpandEnv(m); err != nil {
+				return err
+			}
+		}
+		maps[i] = m
+	}
+
+	// Diff before redacting so changed secrets are still reported
+	changes := zcfg.Diff(maps[0], maps[1])
+	if !*showSecrets {
+		var secretKeys []string
+		for _, m := range maps {
+			keys, err := typeSecretKeys(*typeName, m)
+			if err != nil {
+				return err
+			}
+			secretKeys = append(secretKeys, keys...)
+		}
+		changes = zcfg.RedactChanges(changes, secretKeys...)
+	}
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	if len(changes) > 0 {
+		return &failError{}
+	}
+	return nil
+}
+
+// runGen implements the gen command
+func runGen(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("gen", "<files...>", stderr)
+	pkg := fs.String("package", "config", "package name of the generated source")
+	typeName := fs.String("type", "Config", "name of the root struct")
+	match := fs.String("match", "normal", "key style of meta tags: normal, camel, snake, kebab or screaming-snake")
+	noDefaults := fs.Bool("no-defaults", false, "do not add default= tags from the sample values")
+	output := fs.String("o", "", "output file (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: no config files given", errUsage)
+	}
+
+	mode, err := parseMatchMode(*match)
+	if err != nil {
+		return err
+	}
+
+	m, err := loadFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	source, err := zcfg.GenerateStruct(m,
+		zcfg.WithGeneratePackage(*pkg),
+		zcfg.WithGenerateTypeName(*typeName),
+		zcfg.WithGenerateMatchMode(mode),
+		zcfg.WithGenerateDefaults(!*noDefaults),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(source)
+		return err
+	}
+	return os.WriteFile(*output, source, 0o644)
+}
+
+// parseMatchMode parses a key style flag value
+func parseMatchMode(name string) (zcfg.MatchMode, error) {
+	switch strings.ToLower(name) {
+	case "normal":
+		return zcfg.MatchNormal, nil
+	case "camel":
+		return zcfg.MatchCamelCase, nil
+	case "snake":
+		return zcfg.MatchSnakeCase, nil
+	case "kebab":
+		return zcfg.MatchKebabCase, nil
+	case "screaming-snake":
+		return zcfg.MatchScreamingSnakeCase, nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported key style %q", errUsage, name)
+	}
+}
+
+// writeOutput encodes m and writes it to file, or to stdout if file is empty
+func writeOutput(m map[string]any, format zcfg.Format, file string, stdout io.Writer) error {
+	data, err := zcfg.Marshal(m, format)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
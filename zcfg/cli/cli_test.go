@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type printConfig struct {
+	Conn string `meta:"conn,secret"`
+	Port int    `meta:"port"`
+}
+
+func TestPrintRedactsTaggedSecrets(t *testing.T) {
+	Register[printConfig]("print-test")
+
+	file := filepath.Join(t.TempDir(), "c.json")
+	if err := os.WriteFile(file, []byte(`{"conn":"postgres://u:pw@db","port":80}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"print", "-type", "print-test", file}, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), "pw@db") || !strings.Contains(stdout.String(), "******") {
+		t.Fatalf("secret not redacted: %s", stdout.String())
+	}
+}
@@ -2,25 +2,46 @@ package zcfg
 
 import (
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Config represents a configuration instance
 type Config struct {
-	rawMap  map[string]any
-	target  any
-	file    string
-	option  *Option
-	watcher *FileWatcher
-	mu      sync.RWMutex
-}
-
-// Global registry to track configs by target type
-var (
-	registryMu     sync.RWMutex
-	configRegistry = make(map[reflect.Type]*Config)
-)
+	rawMap       map[string]any
+	secretKeys   map[string]bool
+	target       any
+	file         string
+	fsys         fs.FS
+	base         map[string]any
+	basePos      positionIndex
+	env          *envLookup
+	profile      string
+	option       *Option
+	watcher      *FileWatcher
+	remote       *RemoteSource
+	poller       *RemotePoller
+	name         string
+	registry     *Registry // Registry the config is registered in, guarded by mu
+	unknown      []UnknownKey
+	deprecations []Deprecation
+	warned       map[string]bool
+	origins      map[string]Provenance
+	present      map[string]bool
+	reload       ReloadStatus
+	history      []Snapshot
+	version      int64
+	hash         string
+	restart      map[string]Change
+	mu           sync.RWMutex
+}
 
 // MustLoad loads configuration from file, panics on error
 func MustLoad[T any](file string, opts ...func(*Option)) *T {
@@ -40,8 +61,38 @@ func MustLoadFromJson[T any](content []byte, opts ...func(*Option)) *T {
 	return result
 }
 
-// New creates a new Config instance
+// MustLoadAll loads each document of a configuration file, panics on error
+func MustLoadAll[T any](file string, opts ...func(*Option)) []*T {
+	result, err := LoadAll[T](file, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustLoadFS loads configuration file name from fsys, panics on error
+func MustLoadFS[T any](fsys fs.FS, name string, opts ...func(*Option)) *T {
+	result, err := LoadFS[T](fsys, name, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// New creates a new Config instance and registers it
 func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error) {
+	c, err := newConfig[T](fn, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Register after setup so failed loads don't replace a working config
+	c.option.registry().register(c)
+	return c, nil
+}
+
+// newConfig creates a new Config instance without registering it
+func newConfig[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error) {
 	// Create target instance
 	var target T
 	v := &target
@@ -55,6 +106,7 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 	c := &Config{
 		target: v,
 		option: option,
+		name:   option.Name,
 	}
 
 	// Call the function to setup c
@@ -64,22 +116,58 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 	if c.rawMap == nil {
 		return nil, fmt.Errorf("rawMap is nil")
 	}
+	if err := checkStaticFields(reflect.TypeOf(v), option, "", false, map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+
+	env, err := loadEnvFiles(option)
+	if err != nil {
+		return nil, err
+	}
+	c.env = env
 
-	if option.UseEnv {
-		loadEnvFile()
+	// Merge the active profile and overlay files over the loaded content and render templates
+	c.base = c.rawMap
+	c.profile = resolveProfile(option, env)
+	rawMap, positions, err := c.composeSources(c.rawMap, c.basePos, env)
+	if err != nil {
+		return nil, err
+	}
+	c.rawMap = rawMap
+
+	source := Provenance{Kind: SourceFile, File: c.file, Time: time.Now()}
+	switch {
+	case c.remote != nil:
+		source.Kind = SourceRemote
+		source.File = c.remote.url
+	case c.file == "":
+		source.Kind = SourceData
+	}
+	ctx := newMapContext(option, source)
+	ctx.env = c.env
+	ctx.positions = positions
+	if err := mapToStruct(c.rawMap, v, ctx, false); err != nil {
+		return nil, err
 	}
 
-	if err := mapToStruct(c.rawMap, v, option, false); err != nil {
+	unknown, err := ctx.checkUnknownKeys()
+	if err != nil {
 		return nil, err
 	}
+	c.unknown = unknown
+	c.deprecations = ctx.deprecations
+	c.origins = ctx.provenance
+	c.present = ctx.present
+	c.secretKeys = secretKeySet(reflect.TypeOf(v), c.rawMap, option)
 
-	targetType := reflect.TypeOf(v)
-	registryMu.Lock()
-	configRegistry[targetType] = c
-	registryMu.Unlock()
+	if err := runHooks(v, option, ctx); err != nil {
+		return nil, err
+	}
+	c.recordSnapshot(source)
+	c.logDeprecations(ctx.deprecations)
 
 	// Setup hot reload if enabled
-	if option.HotReload && option.Updatable && c.file != "" {
+	if option.HotReload && option.Updatable && len(c.watchPaths()) > 0 {
 		watcher, err := NewFileWatcher(c)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create file watcher: %w", err)
@@ -89,6 +177,16 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 			return nil, fmt.Errorf("failed to start file watcher: %w", err)
 		}
 	}
+	if option.HotReload && option.Updatable && c.remote != nil {
+		poller, err := NewRemotePoller(c)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create remote poller: %w", err)
+		}
+		c.poller = poller
+		if err := poller.Start(); err != nil {
+			return nil, fmt.Errorf("failed to start remote poller: %w", err)
+		}
+	}
 
 	return c, nil
 }
@@ -96,11 +194,12 @@ func New[T any](fn func(v *Config) error, opts ...func(*Option)) (*Config, error
 // Load loads configuration from file
 func Load[T any](file string, opts ...func(*Option)) (*T, error) {
 	config, err := New[T](func(v *Config) error {
-		rawMap, err := parseConfigFile(file)
+		rawMap, positions, err := readConfigFile(nil, file)
 		if err != nil {
 			return err
 		}
 		v.rawMap = rawMap
+		v.basePos = positions
 		v.file = file
 		return nil
 	}, opts...)
@@ -112,15 +211,88 @@ func Load[T any](file string, opts ...func(*Option)) (*T, error) {
 	return config.target.(*T), nil
 }
 
+// LoadAll loads each document of a multi-document YAML file as a separate configuration,
+// e.g. per-tenant configs stored in one file. Document i is registered with the instance
+// name followed by [i], e.g. "tenants[0]", the name defaults to the file name without
+// extension. Other formats hold a single document. The documents are registered once all
+// of them loaded, replacing the documents of a previous load.
+// Empty documents between others load from an empty map, so result i is document i.
+// Documents are not hot reloaded or polled, reload the file with LoadAll instead.
+func LoadAll[T any](file string, opts ...func(*Option)) ([]*T, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", file, err)
+	}
+	format, err := FormatFromExt(file)
+	if err != nil {
+		return nil, err
+	}
+	docs, positions, err := parseDocuments(data, format, file)
+	if err != nil {
+		return nil, err
+	}
+
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+	name := option.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	// Load all documents before registering any, so a failed load keeps the previous set
+	configs := make([]*Config, 0, len(docs))
+	results := make([]*T, 0, len(docs))
+	for i, doc := range docs {
+		docOpts := append(slices.Clone(opts), WithName(fmt.Sprintf("%s[%d]", name, i)), WithHotReload(false))
+		if doc == nil {
+			doc = make(map[string]any)
+		}
+		config, err := newConfig[T](func(v *Config) error {
+			v.rawMap = doc
+			v.basePos = positions[i]
+			return nil
+		}, docOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		configs = append(configs, config)
+		results = append(results, config.target.(*T))
+	}
+
+	registry := option.registry()
+	for _, c := range configs {
+		registry.register(c)
+	}
+
+	// Unregister the documents of a previous load that had more documents
+	var target *T
+	for i := len(docs); ; i++ {
+		previous := registry.lookup(reflect.TypeOf(target), fmt.Sprintf("%s[%d]", name, i))
+		if previous == nil {
+			break
+		}
+		_ = registry.Unregister(previous)
+	}
+
+	return results, nil
+}
+
 // LoadFromJson loads configuration from JSON bytes
 func LoadFromJson[T any](content []byte, opts ...func(*Option)) (*T, error) {
+	return LoadFromBytes[T](content, FormatJSON, opts...)
+}
+
+// LoadFromBytes loads configuration from content in the given format
+func LoadFromBytes[T any](data []byte, format Format, opts ...func(*Option)) (*T, error) {
 	config, err := New[T](func(v *Config) error {
-		// Parse JSON content
-		rawMap, err := parseJSONBytes(content)
+		rawMap, positions, err := parseSource(data, format, "")
 		if err != nil {
 			return err
 		}
 		v.rawMap = rawMap
+		v.basePos = positions
 		return nil
 	}, opts...)
 
@@ -131,37 +303,72 @@ func LoadFromJson[T any](content []byte, opts ...func(*Option)) (*T, error) {
 	return config.target.(*T), nil
 }
 
-// Get gets Config instance by target type
-func Get[T any]() *Config {
-	var target *T
-	targetType := reflect.TypeOf(target)
+// LoadFromReader loads configuration in the given format from r
+func LoadFromReader[T any](r io.Reader, format Format, opts ...func(*Option)) (*T, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	return LoadFromBytes[T](data, format, opts...)
+}
 
-	registryMu.RLock()
-	config, exists := configRegistry[targetType]
-	registryMu.RUnlock()
+// LoadFS loads configuration file name from fsys, e.g. an embed.FS, the format is chosen by extension
+func LoadFS[T any](fsys fs.FS, name string, opts ...func(*Option)) (*T, error) {
+	config, err := New[T](func(v *Config) error {
+		rawMap, positions, err := readConfigFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		v.rawMap = rawMap
+		v.basePos = positions
+		v.file = name
+		v.fsys = fsys
+		return nil
+	}, opts...)
 
-	if !exists {
+	if err != nil {
+		return nil, err
+	}
+
+	return config.target.(*T), nil
+}
+
+// LoadFromMap loads configuration from a raw map, e.g. one produced by ParseFile and MergeMaps
+func LoadFromMap[T any](m map[string]any, opts ...func(*Option)) (*T, error) {
+	config, err := New[T](func(v *Config) error {
+		v.rawMap = m
 		return nil
+	}, opts...)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return config
+	return config.target.(*T), nil
+}
+
+// Get gets the default (unnamed) Config instance by target type
+func Get[T any]() *Config {
+	return GetNamed[T]("")
 }
 
-// GetMap returns the raw configuration map
+// GetMap returns a copy of the raw configuration map with the values of secret fields masked
 func (c *Config) GetMap() map[string]any {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
 	// Return a copy to prevent external modification
-	result := make(map[string]any)
-	for k, v := range c.rawMap {
-		result[k] = v
-	}
+	result, _ := maskSecretKeys(copyMap(c.rawMap), "", c.secretKeys).(map[string]any)
 	return result
 }
 
 // Update updates configuration with new map
 func (c *Config) Update(m map[string]any) error {
+	return c.update(m, Provenance{Kind: SourceUpdate, Time: time.Now()}, false)
+}
+
+// update applies map m to the target, recording source as origin of the updated values
+func (c *Config) update(m map[string]any, source Provenance, mergePatch bool) error {
 	if !c.option.Updatable {
 		return fmt.Errorf("config is not updatable")
 	}
@@ -169,11 +376,80 @@ func (c *Config) Update(m map[string]any) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.apply(m, nil, source, mergePatch)
+}
+
+// apply maps m onto a copy of the target and commits it if mapping and hooks succeed.
+// Errors are located with positions if m was read from files. In merge patch mode nulls
+// reset fields to their defaults. Caller must hold c.mu.
+func (c *Config) apply(m map[string]any, positions positionIndex, source Provenance, mergePatch bool) error {
+	// Apply the update to a copy so a failed update leaves the target untouched
+	working := cloneValue(reflect.ValueOf(c.target))
+
 	// Update only the fields present in the update map
-	if err := mapToStruct(m, c.target, c.option, true); err != nil {
+	ctx := newMapContext(c.option, source)
+	ctx.env = c.env
+	ctx.mergePatch = mergePatch
+	ctx.positions = positions
+	if err := mapToStruct(m, working.Interface(), ctx, true); err != nil {
+		return fmt.Errorf("failed to update struct: %w", err)
+	}
+
+	unknown, err := ctx.checkUnknownKeys()
+	if err != nil {
+		return fmt.Errorf("failed to update struct: %w", err)
+	}
+
+	// Compare static fields after defaults and normalization, so normalized values don't count as changes
+	if err := runHookPhases(working.Interface(), c.option, ctx, phaseDefaults, phaseNormalize); err != nil {
 		return fmt.Errorf("failed to update struct: %w", err)
 	}
 
+	// Static fields keep their values until restart
+	current := reflect.ValueOf(c.target).Elem()
+	restart := staticChanges(current, working.Elem(), ctx)
+	if len(restart) > 0 && c.option.StaticPolicy == StaticReject {
+		if c.option.RestartCallback != nil {
+			c.option.RestartCallback(restart)
+		}
+		return fmt.Errorf("failed to update struct: %w", &RestartRequiredError{Changes: restart})
+	}
+
+	phases := []hookPhase{phaseValidate}
+	if len(restart) > 0 {
+		// Hooks run again on the restored values, defaults and normalization may depend on them
+		restoreStatic(current, working.Elem(), ctx)
+		for _, change := range restart {
+			ctx.dropRecords(change.Path)
+		}
+		phases = []hookPhase{phaseDefaults, phaseNormalize, phaseValidate}
+	}
+	if err := runHookPhases(working.Interface(), c.option, ctx, phases...); err != nil {
+		return fmt.Errorf("failed to update struct: %w", err)
+	}
+	if err := ctx.notifyWatches(restart); err != nil {
+		return fmt.Errorf("failed to update struct: %w", err)
+	}
+
+	assignValue(reflect.ValueOf(c.target).Elem(), working.Elem())
+	c.unknown = unknown
+	c.deprecations = ctx.deprecations
+	c.origins = mergeProvenance(c.origins, ctx)
+	c.present = mergePresence(c.present, ctx)
+	c.restart = mergeRestart(c.restart, restart, ctx)
+	c.recordSnapshot(source)
+	if c.registry != nil {
+		c.registry.indexFlags(c, c.flagNames())
+	}
+	c.logDeprecations(ctx.deprecations)
+
+	if len(restart) > 0 {
+		c.option.logger().Warn().Str("fields", changePaths(restart)).Msg("config changes require a restart")
+		if c.option.RestartCallback != nil {
+			c.option.RestartCallback(restart)
+		}
+	}
+
 	return nil
 }
 
@@ -193,38 +469,71 @@ func (c *Config) UpdateFromJson(content []byte) error {
 	return c.Update(updateMap)
 }
 
-// GetValue gets value by path
+// UnknownKeys returns keys of the last load or update that no field consumed.
+// In strict mode these cause an error instead.
+func (c *Config) UnknownKeys() []UnknownKey {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]UnknownKey(nil), c.unknown...)
+}
+
+// Deprecations returns deprecated keys used by the last load or update
+func (c *Config) Deprecations() []Deprecation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Deprecation(nil), c.deprecations...)
+}
+
+// GetValue gets value by path, values of secret fields are masked
 func (c *Config) GetValue(path string) (any, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return getNestedValue(c.rawMap, path)
+	value, exists := getNestedValue(c.rawMap, path)
+	if !exists {
+		return nil, false
+	}
+	if isSecretKeyPath(path, c.secretKeys) {
+		return RedactedValue, true
+	}
+	return maskSecretKeys(value, path, c.secretKeys), true
 }
 
-// StartWatcher starts the file watcher
+// StartWatcher starts the file watcher and remote poller
 func (c *Config) StartWatcher() error {
-	if c.watcher == nil {
+	if c.watcher == nil && c.poller == nil {
 		return fmt.Errorf("no watcher configured")
 	}
 
-	if c.watcher.IsRunning() {
-		return nil
+	if c.watcher != nil {
+		if err := c.watcher.Start(); err != nil {
+			return err
+		}
 	}
-
-	return c.watcher.Start()
+	if c.poller != nil {
+		return c.poller.Start()
+	}
+	return nil
 }
 
-// StopWatcher stops the file watcher
+// StopWatcher stops the file watcher and remote poller
 func (c *Config) StopWatcher() error {
-	if c.watcher == nil {
-		return nil
+	if c.poller != nil {
+		if err := c.poller.Stop(); err != nil {
+			return err
+		}
 	}
-
-	return c.watcher.Stop()
+	if c.watcher != nil {
+		return c.watcher.Stop()
+	}
+	return nil
 }
 
-// IsWatcherRunning returns whether the watcher is running
+// IsWatcherRunning returns whether the file watcher or remote poller is running
 func (c *Config) IsWatcherRunning() bool {
+	if c.poller != nil && c.poller.IsRunning() {
+		return true
+	}
 	if c.watcher == nil {
 		return false
 	}
@@ -232,6 +541,23 @@ func (c *Config) IsWatcherRunning() bool {
 	return c.watcher.IsRunning()
 }
 
+// Name returns the instance name the config was registered with
+func (c *Config) Name() string {
+	return c.name
+}
+
+// Close stops the file watcher and removes the config from its registry
+func (c *Config) Close() error {
+	c.mu.RLock()
+	registry := c.registry
+	c.mu.RUnlock()
+
+	if registry != nil {
+		return registry.Unregister(c)
+	}
+	return c.StopWatcher()
+}
+
 // GetTarget returns the target struct pointer
 func (c *Config) GetTarget() any {
 	c.mu.RLock()
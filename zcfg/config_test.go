@@ -0,0 +1,183 @@
+package zcfg
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type tenantConfig struct {
+	Name string `meta:"name"`
+	Port int    `meta:"port"`
+}
+
+func TestLoadAllRegistersAfterLoad(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewRegistry()
+	opts := []func(*Option){WithUseEnv(false), WithRegistry(registry)}
+
+	file := writeFile(t, dir, "tenants.yaml", "name: a\nport: 1\n---\nname: b\nport: 2\n---\nname: c\nport: 3\n")
+	if _, err := LoadAll[tenantConfig](file, opts...); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if GetFrom[tenantConfig](registry, "tenants[2]") == nil {
+		t.Fatal("document 2 is not registered with the file name")
+	}
+
+	writeFile(t, dir, "tenants.yaml", "name: a\nport: 10\n---\nname: b\nport: x\n")
+	if _, err := LoadAll[tenantConfig](file, opts...); err == nil {
+		t.Fatal("expected error")
+	}
+	if c := GetFrom[tenantConfig](registry, "tenants[0]"); c == nil || c.target.(*tenantConfig).Port != 1 {
+		t.Fatal("failed load replaced the previous documents")
+	}
+
+	writeFile(t, dir, "tenants.yaml", "name: a\nport: 10\n")
+	if _, err := LoadAll[tenantConfig](filepath.Join(dir, "tenants.yaml"), opts...); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if c := GetFrom[tenantConfig](registry, "tenants[0]"); c == nil || c.target.(*tenantConfig).Port != 10 {
+		t.Fatal("document 0 is not replaced")
+	}
+	for _, name := range []string{"tenants[1]", "tenants[2]"} {
+		if GetFrom[tenantConfig](registry, name) != nil {
+			t.Fatalf("stale document %s is still registered", name)
+		}
+	}
+}
+
+type optionalTenantConfig struct {
+	Name string `meta:"name,default=default"`
+}
+
+func TestLoadAllKeepsDocumentIndices(t *testing.T) {
+	dir := t.TempDir()
+	opts := []func(*Option){WithUseEnv(false), WithRegistry(NewRegistry())}
+
+	// Empty documents keep their index, a trailing separator adds no document
+	file := writeFile(t, dir, "tenants.yaml", "name: a\n---\n---\nname: c\n---\n")
+	tenants, err := LoadAll[optionalTenantConfig](file, opts...)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(tenants) != 3 || tenants[0].Name != "a" || tenants[1].Name != "default" || tenants[2].Name != "c" {
+		t.Fatalf("unexpected documents: %+v", tenants)
+	}
+
+	writeFile(t, dir, "tenants.yaml", "name: a\nport: 1\n---\n---\nname: c\nport: x\n")
+	if _, err := LoadAll[tenantConfig](file, opts...); err == nil || !strings.HasPrefix(err.Error(), "document 1:") {
+		t.Fatalf("expected error for the empty document 1, got %v", err)
+	}
+}
+
+func TestLoadAllDisablesHotReload(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewRegistry()
+	file := writeFile(t, dir, "tenants.yaml", "name: a\nport: 1\n")
+	overlay := writeFile(t, dir, "overlay.yaml", "port: 2\n")
+
+	tenants, err := LoadAll[tenantConfig](file, WithUseEnv(false), WithRegistry(registry),
+		WithUpdatable(true), WithHotReload(true), WithOverlay(overlay))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if tenants[0].Port != 2 {
+		t.Fatalf("overlay not applied: %+v", tenants[0])
+	}
+	if c := GetFrom[tenantConfig](registry, "tenants[0]"); c == nil || c.watcher != nil {
+		t.Fatal("document is watched")
+	}
+}
+
+type sourceConfig struct {
+	Name    string `meta:"name"`
+	Port    int    `meta:"port,default=80"`
+	Debug   bool   `meta:"debug,optional"`
+	Servers []struct {
+		Host string `meta:"host"`
+	} `meta:"servers,optional"`
+}
+
+func TestLoadFromBytesFormats(t *testing.T) {
+	tests := map[Format]string{
+		FormatJSON: `{"name": "a", "port": 8080, "servers": [{"host": "x"}]}`,
+		FormatYAML: "name: a\nport: 8080\nservers:\n  - host: x\n",
+		FormatTOML: "name = \"a\"\nport = 8080\n[[servers]]\nhost = \"x\"\n",
+	}
+	opts := []func(*Option){WithUseEnv(false), WithRegistry(NewRegistry())}
+	for format, content := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			fromBytes, err := LoadFromBytes[sourceConfig]([]byte(content), format, opts...)
+			if err != nil {
+				t.Fatalf("load bytes: %v", err)
+			}
+			fromReader, err := LoadFromReader[sourceConfig](strings.NewReader(content), format, opts...)
+			if err != nil {
+				t.Fatalf("load reader: %v", err)
+			}
+			for _, target := range []*sourceConfig{fromBytes, fromReader} {
+				if target.Name != "a" || target.Port != 8080 || len(target.Servers) != 1 || target.Servers[0].Host != "x" {
+					t.Fatalf("unexpected config: %+v", target)
+				}
+			}
+		})
+	}
+
+	if _, err := LoadFromBytes[sourceConfig]([]byte("name: a"), Format("ini"), opts...); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+	if _, err := LoadFromBytes[sourceConfig]([]byte("name: [a"), FormatYAML, opts...); err == nil {
+		t.Fatal("expected error for invalid content")
+	}
+}
+
+// errReader fails every read
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestLoadFromReaderError(t *testing.T) {
+	if _, err := LoadFromReader[sourceConfig](errReader{}, FormatJSON, WithUseEnv(false), WithRegistry(NewRegistry())); err == nil || !strings.Contains(err.Error(), "read failed") {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.yaml": {Data: []byte("name: embedded\nport: 8080\n")},
+		"config/app.toml": {Data: []byte("name = \"toml\"\n")},
+	}
+	opts := []func(*Option){WithUseEnv(false), WithRegistry(NewRegistry())}
+
+	target, err := LoadFS[sourceConfig](fsys, "config/app.yaml", opts...)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if target.Name != "embedded" || target.Port != 8080 {
+		t.Fatalf("unexpected config: %+v", target)
+	}
+	if target, err := LoadFS[sourceConfig](fsys, "config/app.toml", opts...); err != nil || target.Name != "toml" || target.Port != 80 {
+		t.Fatalf("unexpected toml config: %+v, %v", target, err)
+	}
+
+	// A real file overlays the embedded defaults
+	overlay := writeFile(t, t.TempDir(), "local.yaml", "debug: true\nport: 9090\n")
+	target, err = LoadFS[sourceConfig](fsys, "config/app.yaml", append(opts, WithOverlay(overlay))...)
+	if err != nil {
+		t.Fatalf("load with overlay: %v", err)
+	}
+	if target.Name != "embedded" || target.Port != 9090 || !target.Debug {
+		t.Fatalf("overlay not applied: %+v", target)
+	}
+
+	if _, err := LoadFS[sourceConfig](fsys, "config/missing.yaml", opts...); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+	if _, err := LoadFS[sourceConfig](fstest.MapFS{"app.ini": {Data: []byte("name=a")}}, "app.ini", opts...); err == nil {
+		t.Fatal("expected error for unknown extension")
+	}
+}
@@ -0,0 +1,81 @@
+package zcfg
+
+import (
+	"fmt"
+)
+
+// Deprecation describes a deprecated key found in the config
+type Deprecation struct {
+	Key         string    // Full path of the deprecated key as written in the config
+	Replacement string    // Full path of the key to use instead, empty if the field itself is deprecated
+	Note        string    // Optional hint from the deprecated= tag option
+	Position    *Position // Position of the key in the config source, nil if unknown
+}
+
+// String returns a message telling operators what to change
+func (d Deprecation) String() string {
+	msg := fmt.Sprintf("config key %s is deprecated", d.Key)
+	if d.Position != nil {
+		msg = fmt.Sprintf("config key %s at %s is deprecated", d.Key, d.Position)
+	}
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", rename it to %s", d.Replacement)
+	}
+	if d.Note != "" {
+		msg += ": " + d.Note
+	}
+	return msg
+}
+
+// lookupFieldKey finds the map key for a field by its name or one of its deprecated aliases.
+// It marks the key as consumed, records deprecations and rejects conflicting keys.
+func lookupFieldKey(fieldName string, tagInfo *TagInfo, ctx *mapContext, visit *mapVisit, basePath, fieldPath string) (string, bool, error) {
+	key, exists := visit.findKey(fieldName, ctx.option)
+	if exists {
+		visit.use(key)
+		if tagInfo.Deprecated {
+			ctx.deprecations = append(ctx.deprecations, Deprecation{
+				Key:      joinPath(basePath, key),
+				Note:     tagInfo.DeprecationNote,
+				Position: ctx.keyPosition(basePath, key),
+			})
+		}
+	}
+
+	for _, alias := range tagInfo.Aliases {
+		aliasKey, found := visit.findKey(alias, ctx.option)
+		if !found {
+			continue
+		}
+		visit.use(aliasKey)
+
+		if exists {
+			return "", false, fmt.Errorf("field %s is set by both '%s' and deprecated alias '%s'", fieldPath, key, aliasKey)
+		}
+		key, exists = aliasKey, true
+
+		ctx.deprecations = append(ctx.deprecations, Deprecation{
+			Key:         joinPath(basePath, aliasKey),
+			Replacement: fieldPath,
+			Note:        tagInfo.DeprecationNote,
+			Position:    ctx.keyPosition(basePath, aliasKey),
+		})
+	}
+
+	return key, exists, nil
+}
+
+// logDeprecations logs a warning for each deprecated key not warned about before.
+// Caller must hold c.mu or own c exclusively.
+func (c *Config) logDeprecations(deprecations []Deprecation) {
+	for _, d := range deprecations {
+		if c.warned[d.Key] {
+			continue
+		}
+		if c.warned == nil {
+			c.warned = make(map[string]bool)
+		}
+		c.warned[d.Key] = true
+		c.option.logger().Warn().Str("key", d.Key).Str("replacement", d.Replacement).Msg(d.String())
+	}
+}
@@ -0,0 +1,44 @@
+package zcfg
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/meta-apex/zenith/zlog"
+)
+
+type deprecatedConfig struct {
+	Timeout int `meta:"timeout,alias=timeout_ms"`
+}
+
+func (c *deprecatedConfig) Validate() error {
+	if c.Timeout > 100 {
+		return errors.New("timeout too large")
+	}
+	return nil
+}
+
+func TestDeprecationsLoggedOnceAfterCommit(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &zlog.Logger{Level: zlog.InfoLevel, Writer: zlog.IOWriter{Writer: &buf}}
+	opts := []func(*Option){WithUseEnv(false), WithUpdatable(true), WithLogger(logger), WithRegistry(NewRegistry())}
+
+	if _, err := LoadFromJson[deprecatedConfig]([]byte(`{"timeout_ms":500}`), opts...); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("warning logged for failed load: %s", buf.String())
+	}
+
+	config, _ := newTestConfig[deprecatedConfig](t, map[string]any{"timeout_ms": 5}, opts...)
+	for i := 0; i < 3; i++ {
+		if err := config.Update(map[string]any{"timeout_ms": i}); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	if n := strings.Count(buf.String(), "timeout_ms is deprecated"); n != 1 {
+		t.Fatalf("expected one warning, got %d: %s", n, buf.String())
+	}
+}
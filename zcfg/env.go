@@ -1,7 +1,9 @@
 package zcfg
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"regexp"
 	"strings"
@@ -10,21 +12,98 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// envVarRegex matches ${VAR} and ${VAR:default}
+var envVarRegex = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
+
+// envLookup resolves environment variables from the process and env files.
+// A nil envLookup resolves from the process environment only.
+type envLookup struct {
+	vars     map[string]string // Variables from env files, later files take precedence
+	paths    []string          // Env files that were read
+	override bool              // Whether env file variables take precedence over the process environment
+}
+
+// loadEnvFiles reads the env files selected by option into a private lookup map.
+// File names may reference environment variables, e.g. .env.${APP_ENV}; files whose
+// name references an unset variable and files that don't exist are skipped.
+func loadEnvFiles(option *Option) (*envLookup, error) {
+	env := &envLookup{
+		vars:     make(map[string]string),
+		override: option.EnvOverride,
+	}
+	if !option.UseEnv {
+		return env, nil
+	}
+
+	for _, name := range option.EnvFiles {
+		// Names may use variables set by earlier env files
+		path, err := processEnvVars(name, true, env)
+		if err != nil {
+			continue
+		}
+
+		vars, err := godotenv.Read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load env file %s: %w", path, err)
+		}
+
+		for key, value := range vars {
+			env.vars[key] = value
+		}
+		env.paths = append(env.paths, path)
+	}
+
+	if option.EnvExport {
+		if err := exportEnv(env); err != nil {
+			return nil, err
+		}
+	}
+
+	return env, nil
+}
+
 var (
-	envOnce sync.Once
+	exportedMu  sync.Mutex
+	exportedEnv = make(map[string]bool) // Variables set in the process environment by exportEnv
 )
 
-// loadEnvFile loads .env file if it exists
-func loadEnvFile() {
-	envOnce.Do(func() {
-		if _, err := os.Stat(".env"); err == nil {
-			_ = godotenv.Load(".env")
+// exportEnv sets the env file variables in the process environment. Variables exported
+// by an earlier load are replaced so reloads see changed env files.
+func exportEnv(env *envLookup) error {
+	exportedMu.Lock()
+	defer exportedMu.Unlock()
+
+	for key, value := range env.vars {
+		if _, exists := os.LookupEnv(key); exists && !env.override && !exportedEnv[key] {
+			continue
 		}
-	})
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to export env variable %s: %w", key, err)
+		}
+		exportedEnv[key] = true
+	}
+	return nil
 }
 
-// processEnvVars processes environment variables in a string value
-func processEnvVars(value string, useEnv bool) (string, error) {
+// lookup returns the value of environment variable name, empty if unset
+func (e *envLookup) lookup(name string) string {
+	if e == nil {
+		return os.Getenv(name)
+	}
+	if value, exists := e.vars[name]; exists && e.override {
+		return value
+	}
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return e.vars[name]
+}
+
+// processEnvVars processes environment variables in a string value, resolving them with env
+func processEnvVars(value string, useEnv bool, env *envLookup) (string, error) {
 	if !useEnv {
 		// Check if value contains env var syntax
 		if strings.Contains(value, "${") {
@@ -33,15 +112,12 @@ func processEnvVars(value string, useEnv bool) (string, error) {
 		return value, nil
 	}
 
-	// Regex to match ${VAR} and ${VAR:default}
-	envVarRegex := regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
-
 	// First, check for any environment variables without default values that don't exist
 	matches := envVarRegex.FindAllStringSubmatch(value, -1)
 	for _, match := range matches {
 		if len(match) >= 2 {
 			envVar := match[1]
-			envValue := os.Getenv(envVar)
+			envValue := env.lookup(envVar)
 
 			// Check if there's a default value (match[2] exists and is not empty or colon is present)
 			hasDefault := len(match) > 2 && strings.Contains(match[0], ":")
@@ -65,7 +141,7 @@ func processEnvVars(value string, useEnv bool) (string, error) {
 			defaultValue = submatches[2]
 		}
 
-		envValue := os.Getenv(envVar)
+		envValue := env.lookup(envVar)
 		if envValue != "" {
 			return envValue
 		}
@@ -77,10 +153,10 @@ func processEnvVars(value string, useEnv bool) (string, error) {
 }
 
 // processEnvValue processes environment variables in any value
-func processEnvValue(value any, useEnv bool) (any, error) {
+func processEnvValue(value any, useEnv bool, env *envLookup) (any, error) {
 	switch v := value.(type) {
 	case string:
-		return processEnvVars(v, useEnv)
+		return processEnvVars(v, useEnv, env)
 	default:
 		return value, nil
 	}
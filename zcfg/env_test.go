@@ -0,0 +1,77 @@
+package zcfg
+
+import (
+	"os"
+	"testing"
+)
+
+type envConfig struct {
+	Host string `meta:"host"`
+	Port string `meta:"port"`
+	User string `meta:"user,default=${ZCFG_TEST_USER:guest}"`
+}
+
+var envRaw = map[string]any{"host": "${ZCFG_TEST_HOST}", "port": "${ZCFG_TEST_PORT:80}"}
+
+// unsetEnv removes exported variables at the end of the test
+func unsetEnv(t *testing.T, keys ...string) {
+	t.Cleanup(func() {
+		exportedMu.Lock()
+		defer exportedMu.Unlock()
+		for _, key := range keys {
+			_ = os.Unsetenv(key)
+			delete(exportedEnv, key)
+		}
+	})
+}
+
+func TestEnvFilesPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, ".env", "ZCFG_TEST_HOST=base\nZCFG_TEST_PORT=8080\nZCFG_TEST_STAGE=local\n")
+	writeFile(t, dir, ".env.local", "ZCFG_TEST_HOST=local\nZCFG_TEST_USER=admin\n")
+	unsetEnv(t, "ZCFG_TEST_HOST", "ZCFG_TEST_PORT", "ZCFG_TEST_STAGE", "ZCFG_TEST_USER")
+	files := WithEnvFiles(base, dir+"/.env.${ZCFG_TEST_STAGE}", dir+"/.env.missing")
+
+	// Later files take precedence, names use variables of earlier files, defaults are expanded
+	_, target := newTestConfig[envConfig](t, envRaw, WithUseEnv(true), WithEnvExport(false), files)
+	if target.Host != "local" || target.Port != "8080" || target.User != "admin" {
+		t.Fatalf("unexpected values: %+v", target)
+	}
+	if _, exists := os.LookupEnv("ZCFG_TEST_HOST"); exists {
+		t.Fatal("env file variable exported with WithEnvExport(false)")
+	}
+
+	// The process environment takes precedence unless EnvOverride is set
+	t.Setenv("ZCFG_TEST_PORT", "9090")
+	_, target = newTestConfig[envConfig](t, envRaw, WithUseEnv(true), WithEnvExport(false), files)
+	if target.Port != "9090" {
+		t.Fatalf("expected process env port 9090, got %s", target.Port)
+	}
+	_, target = newTestConfig[envConfig](t, envRaw, WithUseEnv(true), WithEnvExport(false), WithEnvOverride(true), files)
+	if target.Port != "8080" {
+		t.Fatalf("expected env file port 8080 with override, got %s", target.Port)
+	}
+}
+
+func TestEnvFilesExport(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, ".env", "ZCFG_TEST_HOST=a\nZCFG_TEST_PORT=81\n")
+	unsetEnv(t, "ZCFG_TEST_HOST", "ZCFG_TEST_PORT")
+	t.Setenv("ZCFG_TEST_PORT", "9090")
+
+	// Env files are exported by default without replacing process variables
+	newTestConfig[envConfig](t, envRaw, WithUseEnv(true), WithEnvFiles(path))
+	if host := os.Getenv("ZCFG_TEST_HOST"); host != "a" {
+		t.Fatalf("expected exported host a, got %q", host)
+	}
+	if port := os.Getenv("ZCFG_TEST_PORT"); port != "9090" {
+		t.Fatalf("process variable replaced by export: %q", port)
+	}
+
+	// Reloads replace variables exported earlier
+	writeFile(t, dir, ".env", "ZCFG_TEST_HOST=b\n")
+	_, target := newTestConfig[envConfig](t, envRaw, WithUseEnv(true), WithEnvFiles(path))
+	if target.Host != "b" || os.Getenv("ZCFG_TEST_HOST") != "b" {
+		t.Fatalf("changed env file not applied: host %s, env %s", target.Host, os.Getenv("ZCFG_TEST_HOST"))
+	}
+}
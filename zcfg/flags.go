@@ -0,0 +1,302 @@
+package zcfg
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// Flags is a config section of feature flags keyed by name, e.g.
+//
+//	flags:
+//	  dark-mode: true
+//	  new-checkout:
+//	    enabled: true
+//	    rollout: 25
+//	    allow: [alice]
+//	    deny: [bob]
+//	    rules:
+//	      - attribute: country
+//	        op: in
+//	        values: [DE, FR]
+//
+// A boolean value is shorthand for a flag that is enabled or disabled for everyone.
+type Flags map[string]FlagRule
+
+// FlagRule configures a feature flag. A key is enabled if the flag is enabled, the key is
+// not denied, and it is either allowed or matches all rules and falls into the rollout.
+type FlagRule struct {
+	Enabled bool            `meta:"enabled,optional"`                  // Master switch, the flag is off for everyone if false
+	Rollout float64         `meta:"rollout,default=100,range=[0:100]"` // Percentage of keys enabled
+	Allow   []string        `meta:"allow,optional"`                    // Keys always enabled
+	Deny    []string        `meta:"deny,optional"`                     // Keys always disabled, takes precedence over Allow
+	Rules   []FlagCondition `meta:"rules,optional"`                    // Attribute conditions that must all match
+	Salt    string          `meta:"salt,optional"`                     // Rollout hash salt, default the flag name
+}
+
+// FlagCondition matches an attribute passed with WithFlagAttributes
+type FlagCondition struct {
+	Attribute string   `meta:"attribute"`                       // Attribute name, e.g. country
+	Op        string   `meta:"op,default=in,options=in|not_in"` // Whether the attribute must be in or not in Values
+	Values    []string `meta:"values"`                          // Attribute values
+}
+
+var (
+	flagRuleType      = reflect.TypeOf(FlagRule{})
+	flagConditionType = reflect.TypeOf(FlagCondition{})
+	flagsType         = reflect.TypeOf(Flags(nil)) // Named in schemas so the boolean shorthand validates
+)
+
+// flagRuleValue expands the boolean shorthand of a flag into a rule map
+func flagRuleValue(value any) any {
+	switch v := value.(type) {
+	case bool:
+		return map[string]any{"enabled": v}
+	case string:
+		if v == "true" || v == "false" {
+			return map[string]any{"enabled": v}
+		}
+	}
+	return value
+}
+
+// Enabled reports whether flag name is enabled for key, flags that don't exist are disabled
+func (f Flags) Enabled(ctx context.Context, name, key string) bool {
+	rule, exists := f[name]
+	if !exists {
+		return false
+	}
+	return rule.evaluate(ctx, name, key)
+}
+
+// evaluate reports whether the rule enables the flag name for key
+func (r FlagRule) evaluate(ctx context.Context, name, key string) bool {
+	if !r.Enabled || slices.Contains(r.Deny, key) {
+		return false
+	}
+	if slices.Contains(r.Allow, key) {
+		return true
+	}
+
+	attributes := flagAttributes(ctx)
+	for _, condition := range r.Rules {
+		if !condition.matches(attributes) {
+			return false
+		}
+	}
+
+	switch {
+	case r.Rollout >= 100:
+		return true
+	case r.Rollout <= 0 || key == "":
+		return false
+	}
+
+	salt := r.Salt
+	if salt == "" {
+		salt = name
+	}
+	return rolloutBucket(salt, key) < r.Rollout
+}
+
+// matches checks the condition against attributes
+func (c FlagCondition) matches(attributes map[string]string) bool {
+	value, exists := attributes[c.Attribute]
+	found := exists && slices.Contains(c.Values, value)
+	if c.Op == "not_in" {
+		return !found
+	}
+	return found
+}
+
+// rolloutBucket hashes key with salt into a stable bucket in [0, 100)
+func rolloutBucket(salt, key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(salt + ":" + key))
+	return float64(h.Sum64()%10000) / 100
+}
+
+// flagAttributesKey is the context key for flag attributes
+type flagAttributesKey struct{}
+
+// WithFlagAttributes returns a context carrying attributes for flag rules, e.g. country or plan
+func WithFlagAttributes(ctx context.Context, attributes map[string]string) context.Context {
+	return context.WithValue(ctx, flagAttributesKey{}, attributes)
+}
+
+// flagAttributes returns the flag attributes from the context
+func flagAttributes(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	attributes, _ := ctx.Value(flagAttributesKey{}).(map[string]string)
+	return attributes
+}
+
+// FlagRef refers to a feature flag by name in the Flags sections of registered configs.
+// It is resolved on each evaluation so hot reloads and updates take effect immediately.
+type FlagRef struct {
+	registry *Registry
+	name     string
+}
+
+// Flag returns a reference to feature flag name in configs of the default registry
+func Flag(name string) *FlagRef {
+	return defaultRegistry.Flag(name)
+}
+
+// Flag returns a reference to feature flag name in configs of the registry
+func (r *Registry) Flag(name string) *FlagRef {
+	return &FlagRef{registry: r, name: name}
+}
+
+// Name returns the flag name
+func (f *FlagRef) Name() string {
+	return f.name
+}
+
+// Enabled reports whether the flag is enabled for key, e.g. a user ID.
+// Flags that no registered config defines are disabled.
+func (f *FlagRef) Enabled(ctx context.Context, key string) bool {
+	rule, exists := f.registry.lookupFlag(f.name)
+	if !exists {
+		return false
+	}
+	return rule.evaluate(ctx, f.name, key)
+}
+
+// Exists reports whether a registered config defines the flag
+func (f *FlagRef) Exists() bool {
+	_, exists := f.registry.lookupFlag(f.name)
+	return exists
+}
+
+// lookupFlag finds flag name in the Flags sections of the registered configs, ordered by config name
+func (r *Registry) lookupFlag(name string) (FlagRule, bool) {
+	r.mu.RLock()
+	c := r.flags[name]
+	r.mu.RUnlock()
+
+	if c == nil {
+		return FlagRule{}, false
+	}
+	return c.lookupFlag(name)
+}
+
+// indexFlags records the flag names defined by registered config c and rebuilds the flag index
+func (r *Registry) indexFlags(c *Config, names []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.configs[registryKey{typ: reflect.TypeOf(c.target), name: c.name}] != c {
+		return
+	}
+	r.setFlagNames(c, names)
+}
+
+// setFlagNames records the flag names of config c and rebuilds the flag index. Caller must hold r.mu.
+func (r *Registry) setFlagNames(c *Config, names []string) {
+	if len(names) > 0 {
+		r.flagNames[c] = names
+	} else {
+		delete(r.flagNames, c)
+	}
+
+	configs := make([]*Config, 0, len(r.flagNames))
+	for config := range r.flagNames {
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool {
+		if configs[i].name != configs[j].name {
+			return configs[i].name < configs[j].name
+		}
+		return fmt.Sprint(reflect.TypeOf(configs[i].target)) < fmt.Sprint(reflect.TypeOf(configs[j].target))
+	})
+
+	r.flags = make(map[string]*Config)
+	for _, config := range configs {
+		for _, name := range r.flagNames[config] {
+			if _, exists := r.flags[name]; !exists {
+				r.flags[name] = config
+			}
+		}
+	}
+}
+
+// flagNames returns the names of the flags defined by the config. Caller must hold c.mu.
+func (c *Config) flagNames() []string {
+	v := reflect.ValueOf(c.target).Elem()
+
+	var names []string
+	for _, index := range flagFieldIndexes(v.Type()) {
+		field, err := v.FieldByIndexErr(index)
+		if err != nil {
+			continue
+		}
+		for name := range field.Interface().(Flags) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookupFlag finds flag name in the Flags sections of the config
+func (c *Config) lookupFlag(name string) (FlagRule, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	v := reflect.ValueOf(c.target).Elem()
+	for _, index := range flagFieldIndexes(v.Type()) {
+		field, err := v.FieldByIndexErr(index)
+		if err != nil {
+			continue
+		}
+		if rule, exists := field.Interface().(Flags)[name]; exists {
+			return rule, true
+		}
+	}
+	return FlagRule{}, false
+}
+
+// flagIndexes caches the indexes of Flags fields by struct type
+var flagIndexes sync.Map
+
+// flagFieldIndexes returns the indexes of Flags fields in struct type t and its nested structs
+func flagFieldIndexes(t reflect.Type) [][]int {
+	if cached, ok := flagIndexes.Load(t); ok {
+		return cached.([][]int)
+	}
+
+	var indexes [][]int
+	collectFlagFields(t, nil, &indexes, map[reflect.Type]bool{})
+	flagIndexes.Store(t, indexes)
+	return indexes
+}
+
+// collectFlagFields appends the indexes of Flags fields below prefix in struct type t
+func collectFlagFields(t reflect.Type, prefix []int, indexes *[][]int, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		index := append(slices.Clone(prefix), i)
+		switch {
+		case field.Type == reflect.TypeOf(Flags(nil)):
+			*indexes = append(*indexes, index)
+		case isStructType(field.Type):
+			collectFlagFields(indirectType(field.Type), index, indexes, seen)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package zcfg
+
+import (
+	"context"
+	"testing"
+)
+
+type flagsConfig struct {
+	Flags Flags `cfg:"flags"`
+}
+
+type otherFlagsConfig struct {
+	Flags Flags `meta:"flags"`
+}
+
+func TestFlagRulesWithCustomTagName(t *testing.T) {
+	registry := NewRegistry()
+	config, _ := newTestConfig[flagsConfig](t, map[string]any{"flags": map[string]any{
+		"dark-mode": true,
+		"checkout": map[string]any{
+			"enabled": true,
+			"deny":    []any{"bob"},
+			"rules":   []any{map[string]any{"attribute": "country", "values": []any{"DE"}}},
+		},
+	}}, WithTagName("cfg"), WithRegistry(registry))
+
+	flags := config.target.(*flagsConfig).Flags
+	if rule := flags["checkout"]; rule.Rollout != 100 || len(rule.Rules) != 1 || rule.Rules[0].Op != "in" {
+		t.Fatalf("defaults not applied: %+v", rule)
+	}
+
+	de := WithFlagAttributes(context.Background(), map[string]string{"country": "DE"})
+	fr := WithFlagAttributes(context.Background(), map[string]string{"country": "FR"})
+	switch {
+	case !registry.Flag("dark-mode").Enabled(context.Background(), "alice"):
+		t.Fatal("dark-mode should be enabled")
+	case !registry.Flag("checkout").Enabled(de, "alice"):
+		t.Fatal("checkout should be enabled for DE")
+	case registry.Flag("checkout").Enabled(fr, "alice"):
+		t.Fatal("checkout should be disabled for FR")
+	case registry.Flag("checkout").Enabled(de, "bob"):
+		t.Fatal("checkout should be disabled for denied keys")
+	}
+}
+
+func TestFlagRollout(t *testing.T) {
+	flags := Flags{"beta": {Enabled: true, Rollout: 30}}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		key := string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + string(rune('a'+i/676))
+		if flags.Enabled(context.Background(), "beta", key) != flags.Enabled(context.Background(), "beta", key) {
+			t.Fatalf("rollout not stable for %s", key)
+		}
+		if flags.Enabled(context.Background(), "beta", key) {
+			enabled++
+		}
+	}
+	if enabled < 200 || enabled > 400 {
+		t.Fatalf("rollout of 30%% enabled %d of 1000 keys", enabled)
+	}
+}
+
+func TestFlagIndex(t *testing.T) {
+	registry := NewRegistry()
+	b, _ := newTestConfig[otherFlagsConfig](t, map[string]any{"flags": map[string]any{"shared": true}}, WithName("b"), WithRegistry(registry))
+	a, _ := newTestConfig[otherFlagsConfig](t, map[string]any{"flags": map[string]any{"shared": false}}, WithName("a"), WithRegistry(registry))
+
+	shared := registry.Flag("shared")
+	if shared.Enabled(context.Background(), "alice") {
+		t.Fatal("config a should take precedence by name")
+	}
+
+	if err := b.Update(map[string]any{"flags": map[string]any{"shared": true, "added": true}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !registry.Flag("added").Exists() {
+		t.Fatal("flag added by update is not indexed")
+	}
+
+	if err := registry.Unregister(a); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+	if !shared.Enabled(context.Background(), "alice") {
+		t.Fatal("config b should define the flag after a is unregistered")
+	}
+
+	if err := registry.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if shared.Exists() {
+		t.Fatal("flag exists after close")
+	}
+}
@@ -0,0 +1,395 @@
+package zcfg
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// GenerateOption represents struct generation options
+type GenerateOption struct {
+	Package   string    // Package name, default "config"
+	TypeName  string    // Name of the root struct, default "Config"
+	MatchMode MatchMode // Key style of the meta tags, sample keys are kept where the style would not match them
+	Defaults  bool      // Whether to add default= tags from the sample values
+}
+
+// NewGenerateOption creates a new GenerateOption with default values
+func NewGenerateOption() *GenerateOption {
+	return &GenerateOption{
+		Package:   "config",
+		TypeName:  "Config",
+		MatchMode: MatchNormal,
+		Defaults:  true,
+	}
+}
+
+// WithGeneratePackage sets the package name of the generated source
+func WithGeneratePackage(name string) func(*GenerateOption) {
+	return func(o *GenerateOption) {
+		o.Package = name
+	}
+}
+
+// WithGenerateTypeName sets the name of the root struct
+func WithGenerateTypeName(name string) func(*GenerateOption) {
+	return func(o *GenerateOption) {
+		o.TypeName = name
+	}
+}
+
+// WithGenerateMatchMode sets the key style of the meta tags, e.g. MatchSnakeCase for max_conns
+func WithGenerateMatchMode(mode MatchMode) func(*GenerateOption) {
+	return func(o *GenerateOption) {
+		o.MatchMode = mode
+	}
+}
+
+// WithGenerateDefaults sets whether to add default= tags from the sample values
+func WithGenerateDefaults(enabled bool) func(*GenerateOption) {
+	return func(o *GenerateOption) {
+		o.Defaults = enabled
+	}
+}
+
+// GenerateStruct generates Go source declaring structs that mirror the sample config, e.g. one
+// parsed with ParseFile. Nested maps become nested struct types, lists of maps become slices of
+// structs whose fields are the union of the element keys, and strings like 30s become
+// time.Duration. Keys that look sensitive get the secret tag option and no default.
+func GenerateStruct(sample map[string]any, opts ...func(*GenerateOption)) ([]byte, error) {
+	option := NewGenerateOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+	if !isGoIdentifier(option.Package) {
+		return nil, fmt.Errorf("invalid package name '%s'", option.Package)
+	}
+	if !isGoIdentifier(option.TypeName) {
+		return nil, fmt.Errorf("invalid type name '%s'", option.TypeName)
+	}
+
+	g := &generator{
+		option: option,
+		names:  make(map[string]bool),
+	}
+	g.structType(option.TypeName, "", []map[string]any{sample})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", option.Package)
+	if g.usesTime {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	tagName := NewOption().TagName
+	for i, s := range g.structs {
+		if i == 0 {
+			fmt.Fprintf(&buf, "// %s is generated from a sample config\n", s.name)
+		} else {
+			fmt.Fprintf(&buf, "// %s is generated from the %s section\n", s.name, s.key)
+		}
+		fmt.Fprintf(&buf, "type %s struct {\n", s.name)
+		for _, f := range s.fields {
+			fmt.Fprintf(&buf, "\t%s %s `%s:%s`\n", f.name, f.typ, tagName, strconv.Quote(f.tag))
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated source: %w", err)
+	}
+	return source, nil
+}
+
+// genStruct is a generated struct type
+type genStruct struct {
+	name   string
+	key    string // Sample key the struct was generated from
+	fields []genField
+}
+
+// genField is a field of a generated struct type
+type genField struct {
+	name string
+	typ  string
+	tag  string
+}
+
+// generator collects struct types while walking a sample config
+type generator struct {
+	option   *GenerateOption
+	structs  []*genStruct
+	names    map[string]bool
+	usesTime bool
+}
+
+// structType generates a struct type for the sample maps and returns its name.
+// Keys missing from some samples, null, empty strings and empty lists become optional fields.
+func (g *generator) structType(key, parent string, samples []map[string]any) string {
+	s := &genStruct{
+		name: g.typeName(key, parent),
+		key:  key,
+	}
+	g.structs = append(g.structs, s)
+
+	var keys []string
+	seen := make(map[string]bool)
+	for _, sample := range samples {
+		for k := range sample {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	fieldNames := make(map[string]bool)
+	for _, k := range keys {
+		var values []any
+		for _, sample := range samples {
+			if value, exists := sample[k]; exists && value != nil {
+				values = append(values, value)
+			}
+		}
+
+		name := uniqueName(goIdentifier(k), fieldNames)
+		typ, def := g.fieldType(k, s.name, values)
+		secret := isSecretKey(k)
+
+		tag := []string{g.tagName(k, name)}
+		switch {
+		case secret:
+			tag = append(tag, "secret")
+			if len(values) < len(samples) {
+				tag = append(tag, "optional")
+			}
+		case def != "" && g.option.Defaults:
+			tag = append(tag, "default="+def)
+		case len(values) < len(samples), typ == "string" && def == "", typ == "[]any":
+			tag = append(tag, "optional")
+		}
+
+		s.fields = append(s.fields, genField{name: name, typ: typ, tag: strings.Join(tag, ",")})
+	}
+	return s.name
+}
+
+// fieldType returns the Go type for the sample values of key and a default from the first value
+func (g *generator) fieldType(key, parent string, values []any) (string, string) {
+	if len(values) == 0 {
+		return "any", ""
+	}
+
+	var maps []map[string]any
+	var lists, scalars []any
+	for _, value := range values {
+		if m, ok := toStringMap(value); ok {
+			maps = append(maps, m)
+		} else if list, ok := toSlice(value); ok {
+			lists = append(lists, list...)
+		} else {
+			scalars = append(scalars, value)
+		}
+	}
+
+	switch {
+	case len(maps) == len(values):
+		return g.structType(key, parent, maps), ""
+	case len(scalars) == 0 && len(maps) == 0:
+		if len(lists) == 0 {
+			return "[]any", ""
+		}
+		elem, _ := g.fieldType(singular(key), parent, lists)
+		return "[]" + elem, ""
+	case len(scalars) == len(values):
+		return g.scalarType(scalars)
+	default:
+		return "any", ""
+	}
+}
+
+// scalarType returns the Go type shared by the scalar values and a default from the first value
+func (g *generator) scalarType(values []any) (string, string) {
+	kinds := make(map[string]bool)
+	for _, value := range values {
+		kinds[scalarKind(value)] = true
+	}
+	if kinds["int"] && kinds["float64"] {
+		delete(kinds, "int")
+	}
+	if kinds["time.Duration"] && kinds["string"] {
+		delete(kinds, "time.Duration")
+	}
+	if len(kinds) != 1 {
+		return "any", ""
+	}
+
+	var kind string
+	for k := range kinds {
+		kind = k
+	}
+	if kind == "any" {
+		return "any", ""
+	}
+	if kind == "time.Duration" {
+		g.usesTime = true
+	}
+
+	def := fmt.Sprint(values[0])
+	if f, ok := values[0].(float64); ok {
+		def = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	// Commas separate tag options and backticks end the tag literal
+	if strings.ContainsAny(def, ",`") {
+		def = ""
+	}
+	return kind, def
+}
+
+// scalarKind returns the Go type of a scalar sample value
+func scalarKind(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return "int"
+		}
+		return "float64"
+	case string:
+		if isDurationString(v) {
+			return "time.Duration"
+		}
+		return "string"
+	default:
+		return "any"
+	}
+}
+
+// isDurationString checks if s is a duration with units, e.g. 30s or 1h30m
+func isDurationString(s string) bool {
+	if !strings.ContainsAny(s, "hmsuµn") {
+		return false
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+// typeName returns an unused struct type name for key, qualified with parent on collisions
+func (g *generator) typeName(key, parent string) string {
+	name := goIdentifier(key)
+	if g.names[name] && parent != "" {
+		name = parent + name
+	}
+	return uniqueName(name, g.names)
+}
+
+// tagName returns the tag name for key in the chosen key style if it matches key, otherwise key
+func (g *generator) tagName(key, fieldName string) string {
+	if g.option.MatchMode == MatchNormal {
+		return key
+	}
+
+	option := NewOption()
+	option.MatchMode = g.option.MatchMode
+	styled := convertFieldName(fieldName, option.MatchMode)
+	if found, exists := findKeyInMap(map[string]any{key: nil}, styled, option); exists && found == key {
+		return styled
+	}
+	return key
+}
+
+// uniqueName returns name, or name with a number appended if it is in used, and marks it used
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
+
+// goInitialisms are words written in upper case in Go identifiers
+var goInitialisms = []string{
+	"ACL", "API", "CPU", "DB", "DNS", "HTTP", "HTTPS", "ID", "IP", "JSON", "JWT", "OS", "QPS",
+	"RPC", "SQL", "SSH", "TCP", "TLS", "TTL", "UDP", "UI", "URI", "URL", "UUID", "XML", "YAML",
+}
+
+// goIdentifier converts a config key like max_conns, maxConns or api-url to an exported Go name
+func goIdentifier(key string) string {
+	var words []string
+	var word []rune
+	runes := []rune(key)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			words = append(words, string(word))
+			word = nil
+			continue
+		case unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])):
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	words = append(words, string(word))
+
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		switch {
+		case w == "":
+		case slices.Contains(goInitialisms, upper):
+			b.WriteString(upper)
+		case w == upper:
+			r := []rune(w)
+			b.WriteString(string(r[0]) + strings.ToLower(string(r[1:])))
+		default:
+			r := []rune(w)
+			b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+		}
+	}
+
+	name := b.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		return "X" + name
+	}
+	return name
+}
+
+// isGoIdentifier checks if s is a valid Go identifier
+func isGoIdentifier(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// singular returns the singular form of a plural key, e.g. upstreams to upstream
+func singular(key string) string {
+	switch {
+	case strings.HasSuffix(key, "ies"):
+		return strings.TrimSuffix(key, "ies") + "y"
+	case strings.HasSuffix(key, "sses"), strings.HasSuffix(key, "uses"), strings.HasSuffix(key, "xes"),
+		strings.HasSuffix(key, "ches"), strings.HasSuffix(key, "shes"):
+		return strings.TrimSuffix(key, "es")
+	case strings.HasSuffix(key, "s") && !strings.HasSuffix(key, "ss"):
+		return strings.TrimSuffix(key, "s")
+	default:
+		return key
+	}
+}
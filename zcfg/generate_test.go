@@ -0,0 +1,110 @@
+package zcfg
+
+import (
+	"testing"
+)
+
+func TestGenerateStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		opts   []func(*GenerateOption)
+		want   string
+	}{
+		{
+			name: "nested maps",
+			sample: `
+server:
+  http:
+    port: 8080
+    timeout: 30s
+  limits:
+    max_conns: 100
+    rate: 1.5
+`,
+			want: "package config\n\nimport \"time\"\n\n" +
+				"// Config is generated from a sample config\n" +
+				"type Config struct {\n\tServer Server `meta:\"server\"`\n}\n\n" +
+				"// Server is generated from the server section\n" +
+				"type Server struct {\n\tHTTP   HTTP   `meta:\"http\"`\n\tLimits Limits `meta:\"limits\"`\n}\n\n" +
+				"// HTTP is generated from the http section\n" +
+				"type HTTP struct {\n\tPort    int           `meta:\"port,default=8080\"`\n\tTimeout time.Duration `meta:\"timeout,default=30s\"`\n}\n\n" +
+				"// Limits is generated from the limits section\n" +
+				"type Limits struct {\n\tMaxConns int     `meta:\"max_conns,default=100\"`\n\tRate     float64 `meta:\"rate,default=1.5\"`\n}\n",
+		},
+		{
+			name: "lists of objects",
+			sample: `
+upstreams:
+  - host: a.example.com
+    weight: 2
+  - host: b.example.com
+    api_key: secret
+ports: [80, 443]
+`,
+			want: "package config\n\n" +
+				"// Config is generated from a sample config\n" +
+				"type Config struct {\n\tPorts     []int      `meta:\"ports\"`\n\tUpstreams []Upstream `meta:\"upstreams\"`\n}\n\n" +
+				"// Upstream is generated from the upstream section\n" +
+				"type Upstream struct {\n\tAPIKey string `meta:\"api_key,secret,optional\"`\n\tHost   string `meta:\"host,default=a.example.com\"`\n\tWeight int    `meta:\"weight,default=2\"`\n}\n",
+		},
+		{
+			name: "mixed-type lists",
+			sample: `
+values: [1, "two", 3.5]
+items:
+  - 1
+  - name: x
+tags: []
+ratios: [1, 2.5]
+`,
+			want: "package config\n\n" +
+				"// Config is generated from a sample config\n" +
+				"type Config struct {\n\tItems  []any     `meta:\"items,optional\"`\n\tRatios []float64 `meta:\"ratios\"`\n" +
+				"\tTags   []any     `meta:\"tags,optional\"`\n\tValues []any     `meta:\"values,optional\"`\n}\n",
+		},
+		{
+			name: "options",
+			sample: `
+maxConns: 10
+db:
+  maxConns: 5
+`,
+			opts: []func(*GenerateOption){
+				WithGeneratePackage("app"),
+				WithGenerateTypeName("Settings"),
+				WithGenerateMatchMode(MatchCamelCase),
+				WithGenerateDefaults(false),
+			},
+			want: "package app\n\n" +
+				"// Settings is generated from a sample config\n" +
+				"type Settings struct {\n\tDB       DB  `meta:\"db\"`\n\tMaxConns int `meta:\"maxConns\"`\n}\n\n" +
+				"// DB is generated from the db section\n" +
+				"type DB struct {\n\tMaxConns int `meta:\"maxConns\"`\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, err := ParseBytes([]byte(tt.sample), FormatYAML)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := GenerateStruct(sample, tt.opts...)
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("unexpected source:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateStructInvalidNames(t *testing.T) {
+	if _, err := GenerateStruct(map[string]any{}, WithGeneratePackage("my-app")); err == nil {
+		t.Fatal("expected error for invalid package name")
+	}
+	if _, err := GenerateStruct(map[string]any{}, WithGenerateTypeName("1Config")); err == nil {
+		t.Fatal("expected error for invalid type name")
+	}
+}
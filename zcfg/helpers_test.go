@@ -0,0 +1,37 @@
+package zcfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestConfig loads raw into a config of type T that is updatable, ignores the
+// environment and is registered in its own registry. opts override these defaults.
+func loadTestConfig[T any](raw map[string]any, opts ...func(*Option)) (*Config, error) {
+	defaults := []func(*Option){WithUseEnv(false), WithUpdatable(true), WithRegistry(NewRegistry())}
+	return New[T](func(c *Config) error {
+		c.rawMap = raw
+		return nil
+	}, append(defaults, opts...)...)
+}
+
+// newTestConfig loads raw like loadTestConfig and fails the test on errors
+func newTestConfig[T any](t *testing.T, raw map[string]any, opts ...func(*Option)) (*Config, *T) {
+	t.Helper()
+	config, err := loadTestConfig[T](raw, opts...)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	return config, config.GetTarget().(*T)
+}
+
+// writeFile writes content to file name in dir and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
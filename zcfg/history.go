@@ -0,0 +1,123 @@
+package zcfg
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Snapshot describes a configuration state applied by a load, update, reload or rollback
+type Snapshot struct {
+	Version int64      `json:"version"`           // Version number, increases with each applied change
+	Time    time.Time  `json:"time"`              // Time the snapshot was applied
+	Source  SourceKind `json:"source"`            // Kind of source that produced the snapshot
+	File    string     `json:"file,omitempty"`    // Source file, empty if not loaded from a file
+	Hash    string     `json:"hash"`              // HMAC-SHA256 of the effective configuration with a per-process key
+	Changes []Change   `json:"changes,omitempty"` // Changes to the previous snapshot, secret values are masked
+
+	state map[string]any // Effective configuration for rollback
+	view  map[string]any // Effective configuration with secret digests for diffing
+}
+
+// digestKey is a per-process random key for hashes of states that contain secrets,
+// so exposed hashes can't be used to guess secrets offline
+var digestKey = func() []byte {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("zcfg: failed to generate digest key: %v", err))
+	}
+	return key
+}()
+
+// keyedHash returns the HMAC-SHA256 of data with digestKey
+func keyedHash(data []byte) []byte {
+	mac := hmac.New(sha256.New, digestKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// recordSnapshot records the current target as a new version if it changed. Caller must hold c.mu.
+func (c *Config) recordSnapshot(source Provenance) {
+	state, _ := redactValue(reflect.ValueOf(c.target), c.option, false, nil).(map[string]any)
+	data, _ := json.Marshal(state)
+	hash := hex.EncodeToString(keyedHash(data))
+	if hash == c.hash {
+		return
+	}
+	c.hash = hash
+	c.version++
+
+	if c.option.HistorySize <= 0 {
+		return
+	}
+
+	snapshot := Snapshot{
+		Version: c.version,
+		Time:    source.Time,
+		Source:  source.Kind,
+		File:    source.File,
+		Hash:    hash,
+		state:   state,
+		view:    c.digestView(),
+	}
+	if len(c.history) > 0 {
+		snapshot.Changes = Diff(c.history[len(c.history)-1].view, snapshot.view)
+	}
+
+	c.history = append(c.history, snapshot)
+	if len(c.history) > c.option.HistorySize {
+		c.history = append([]Snapshot(nil), c.history[len(c.history)-c.option.HistorySize:]...)
+	}
+}
+
+// Version returns the version of the current configuration, starting at 1 after load
+func (c *Config) Version() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// History returns the retained snapshots, oldest first
+func (c *Config) History() []Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Snapshot(nil), c.history...)
+}
+
+// RollbackTo re-applies the snapshot with version through the normal update path,
+// so validation, hooks and watch callbacks run. The rollback is recorded as a new version.
+func (c *Config) RollbackTo(version int64) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var snapshot *Snapshot
+	for i := range c.history {
+		if c.history[i].Version == version {
+			snapshot = &c.history[i]
+			break
+		}
+	}
+	if snapshot == nil {
+		return fmt.Errorf("version %d is not in history", version)
+	}
+
+	current, _ := redactValue(reflect.ValueOf(c.target), c.option, false, nil).(map[string]any)
+	patch := createMergePatch(current, snapshot.state)
+	if len(patch) == 0 {
+		return nil
+	}
+
+	if err := c.apply(patch, nil, Provenance{Kind: SourceRollback, Time: time.Now()}, true); err != nil {
+		return fmt.Errorf("failed to roll back to version %d: %w", version, err)
+	}
+	return nil
+}
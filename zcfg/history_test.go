@@ -0,0 +1,78 @@
+package zcfg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type historyConfig struct {
+	Port     int    `meta:"port"`
+	Password string `meta:"password,secret"`
+}
+
+func TestHistoryAndRollback(t *testing.T) {
+	config, target := newTestConfig[historyConfig](t, map[string]any{"port": 80, "password": "a"}, WithHistorySize(2))
+
+	// Updates without changes do not create versions
+	if err := config.Update(map[string]any{"port": 80}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if v := config.Version(); v != 1 {
+		t.Fatalf("expected version 1, got %d", v)
+	}
+
+	if err := config.Update(map[string]any{"port": 81, "password": "b"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := config.Update(map[string]any{"port": 82}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	history := config.History()
+	if len(history) != 2 || history[0].Version != 2 || history[1].Version != 3 {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+	for _, change := range history[0].Changes {
+		if change.Path == "password" && (fmt.Sprint(change.Old) != RedactedValue || fmt.Sprint(change.New) != RedactedValue) {
+			t.Fatalf("secret change not masked: %v", change)
+		}
+	}
+
+	if err := config.RollbackTo(1); err == nil {
+		t.Fatal("expected error for version outside history")
+	}
+	if err := config.RollbackTo(2); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if target.Port != 81 || target.Password != "b" || config.Version() != 4 {
+		t.Fatalf("unexpected state after rollback: %+v version %d", target, config.Version())
+	}
+	if source := config.History()[1].Source; source != SourceRollback {
+		t.Fatalf("unexpected rollback source %v", source)
+	}
+}
+
+func TestHistoryHidesSecrets(t *testing.T) {
+	config, _ := newTestConfig[historyConfig](t, map[string]any{"port": 80, "password": "a"})
+	if err := config.Update(map[string]any{"password": "hunter2"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if v := config.Version(); v != 2 {
+		t.Fatalf("secret change did not create a version, got %d", v)
+	}
+
+	// Plain hashes of the secret and the unmasked state could be brute-forced offline
+	state, _ := json.Marshal(map[string]any{"port": 80, "password": "hunter2"})
+	stateSum := sha256.Sum256(state)
+	secretSum := sha256.Sum256([]byte(`"hunter2"`))
+	data, _ := json.Marshal(config.History())
+	for _, leaked := range []string{"hunter2", hex.EncodeToString(stateSum[:]), hex.EncodeToString(secretSum[:])} {
+		if strings.Contains(string(data), leaked) {
+			t.Fatalf("history exposes %s: %s", leaked, data)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Defaulter is implemented by config structs that compute defaults after mapping
+type Defaulter interface {
+	SetDefaults()
+}
+
+// Normalizer is implemented by config structs that normalize values after defaults are set
+type Normalizer interface {
+	Normalize()
+}
+
+// Validator is implemented by config structs that check values, e.g. across fields
+type Validator interface {
+	Validate() error
+}
+
+// hookPhase is one pass of lifecycle hooks over the config tree
+type hookPhase int
+
+const (
+	phaseDefaults hookPhase = iota
+	phaseNormalize
+	phaseValidate
+)
+
+// runHooks calls lifecycle hooks on target and all nested structs.
+// Each phase runs over the whole tree before the next one starts, in the order
+// SetDefaults, Normalize, Validate. Nested structs are visited before their parent.
+// Validation errors are located with the source positions recorded in ctx.
+func runHooks(target any, option *Option, ctx *mapContext) error {
+	return runHookPhases(target, option, ctx, phaseDefaults, phaseNormalize, phaseValidate)
+}
+
+// runHookPhases runs the hooks of phases on target in order
+func runHookPhases(target any, option *Option, ctx *mapContext, phases ...hookPhase) error {
+	v := reflect.ValueOf(target)
+	for _, phase := range phases {
+		if err := runHooksWithPath(v, option, ctx, "", phase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHooksWithPath runs one hook phase on value and its children with field path tracking
+func runHooksWithPath(v reflect.Value, option *Option, ctx *mapContext, fieldPath string, phase hookPhase) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		if elem.Kind() == reflect.Struct && !elem.CanAddr() {
+			// Struct stored by value in an interface, hooks with pointer receivers need a copy
+			ptr := reflect.New(elem.Type())
+			ptr.Elem().Set(elem)
+			if err := runHooksWithPath(ptr.Elem(), option, ctx, fieldPath, phase); err != nil {
+				return err
+			}
+			if v.CanSet() {
+				v.Set(ptr.Elem())
+			}
+			return nil
+		}
+		return runHooksWithPath(elem, option, ctx, fieldPath, phase)
+
+	case reflect.Struct:
+		if isUnitType(v.Type()) {
+			return nil
+		}
+		if err := runFieldHooks(v, option, ctx, fieldPath, phase); err != nil {
+			return err
+		}
+
+		if v.CanAddr() {
+			return ctx.locate(callHook(v.Addr(), fieldPath, phase), fieldPath)
+		}
+		return ctx.locate(callHook(v, fieldPath, phase), fieldPath)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := runHooksWithPath(v.Index(i), option, ctx, fmt.Sprintf("%s[%d]", fieldPath, i), phase); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			// Map values are not addressable, run hooks on a copy and store it back
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := runHooksWithPath(elem, option, ctx, fmt.Sprintf("%s[%v]", fieldPath, iter.Key().Interface()), phase); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	}
+
+	return nil
+}
+
+// runFieldHooks runs hooks on the fields of a struct without calling its own hooks.
+// Embedded structs share the parent path, their hooks are promoted to the parent.
+func runFieldHooks(v reflect.Value, option *Option, ctx *mapContext, fieldPath string, phase hookPhase) error {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+
+		if fieldType.Anonymous {
+			if err := runFieldHooks(field, option, ctx, fieldPath, phase); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		childPath := fieldName
+		if fieldPath != "" {
+			childPath = fieldPath + "." + fieldName
+		}
+
+		if err := runHooksWithPath(field, option, ctx, childPath, phase); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// callHook calls the hook of the given phase if value implements it
+func callHook(v reflect.Value, fieldPath string, phase hookPhase) error {
+	if !v.CanInterface() {
+		return nil
+	}
+
+	switch phase {
+	case phaseDefaults:
+		if d, ok := v.Interface().(Defaulter); ok {
+			d.SetDefaults()
+		}
+	case phaseNormalize:
+		if n, ok := v.Interface().(Normalizer); ok {
+			n.Normalize()
+		}
+	case phaseValidate:
+		if val, ok := v.Interface().(Validator); ok {
+			if err := val.Validate(); err != nil {
+				if fieldPath == "" {
+					return fmt.Errorf("config validation failed: %w", err)
+				}
+				return fmt.Errorf("field %s validation failed: %w", fieldPath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// watchEvent is an updated watched field
+type watchEvent struct {
+	path     string
+	key      string
+	oldValue any
+	newValue any
+	secret   bool
+}
+
+// recordWatch records the change of a watched field, reported once the update passed validation
+func (ctx *mapContext) recordWatch(fieldPath, key string, oldValue, newValue any, secret bool) {
+	ctx.watches = append(ctx.watches, watchEvent{
+		path:     fieldPath,
+		key:      key,
+		oldValue: oldValue,
+		newValue: newValue,
+		secret:   secret,
+	})
+}
+
+// notifyWatches calls the watch callback for the watched fields of a validated update,
+// skipping fields below the static changes that are not applied. Secret values are masked.
+// A callback error rejects the update.
+func (ctx *mapContext) notifyWatches(skipped []Change) error {
+	for _, event := range ctx.watches {
+		if slices.ContainsFunc(skipped, func(change Change) bool { return isSubPath(event.path, change.Path) }) {
+			continue
+		}
+		oldValue, newValue := event.oldValue, event.newValue
+		if event.secret {
+			oldValue, newValue = RedactedValue, RedactedValue
+		}
+		if err := ctx.option.WatchCallback(event.path, event.key, oldValue, newValue); err != nil {
+			return fmt.Errorf("watch callback error for field %s: %w", event.path, err)
+		}
+	}
+	return nil
+}
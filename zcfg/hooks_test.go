@@ -0,0 +1,95 @@
+package zcfg
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type watchedConfig struct {
+	Level string `meta:"level,watch"`
+	Port  int    `meta:"port"`
+}
+
+func (c *watchedConfig) Validate() error {
+	if c.Port <= 0 {
+		return errors.New("port must be positive")
+	}
+	return nil
+}
+
+func TestWatchCallbackAfterValidation(t *testing.T) {
+	var changes []string
+	callback := func(path, key string, oldValue, newValue any) error {
+		changes = append(changes, path+"="+newValue.(string))
+		return nil
+	}
+
+	config, _ := newTestConfig[watchedConfig](t, map[string]any{"level": "info", "port": 80}, WithWatchCallback(callback))
+
+	if err := config.Update(map[string]any{"level": "debug", "port": -1}); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if len(changes) != 0 {
+		t.Fatalf("callback fired for rejected update: %v", changes)
+	}
+
+	if err := config.Update(map[string]any{"level": "debug"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !reflect.DeepEqual(changes, []string{"level=debug"}) {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestWatchCallbackRejectsUpdate(t *testing.T) {
+	callback := func(path, key string, oldValue, newValue any) error {
+		if newValue == "trace" {
+			return errors.New("trace is not allowed")
+		}
+		return nil
+	}
+
+	config, target := newTestConfig[watchedConfig](t, map[string]any{"level": "info", "port": 80}, WithWatchCallback(callback))
+
+	if err := config.Update(map[string]any{"level": "trace", "port": 81}); err == nil {
+		t.Fatal("expected callback error")
+	}
+	if target.Level != "info" || target.Port != 80 {
+		t.Fatalf("rejected update was applied: %+v", target)
+	}
+}
+
+func TestCloneValueCycle(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	a := &node{Name: "a"}
+	a.Next = &node{Name: "b", Next: a}
+
+	clone := cloneValue(reflect.ValueOf(a)).Interface().(*node)
+	if clone == a || clone.Next.Next != clone || clone.Next.Name != "b" {
+		t.Fatal("cycle not preserved in clone")
+	}
+}
+
+type cyclicConfig struct {
+	Name string        `meta:"name"`
+	Self *cyclicConfig `meta:"_"`
+}
+
+func (c *cyclicConfig) SetDefaults() {
+	c.Self = c
+}
+
+func TestUpdateCyclicConfig(t *testing.T) {
+	config, target := newTestConfig[cyclicConfig](t, map[string]any{"name": "a"})
+
+	if err := config.Update(map[string]any{"name": "b"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if target.Name != "b" || target.Self != target {
+		t.Fatalf("unexpected target after update: %s", target.Name)
+	}
+}
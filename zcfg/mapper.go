@@ -7,13 +7,41 @@ import (
 	"time"
 )
 
+// mapContext carries state collected during one mapping pass
+type mapContext struct {
+	option       *Option
+	visits       []*mapVisit           // Maps mapped onto structs, in visit order
+	byMap        map[uintptr]*mapVisit // Visits indexed by map identity
+	deprecations []Deprecation         // Deprecated keys found while mapping
+	source       Provenance            // Origin of values read from the map
+	provenance   map[string]Provenance // Origin of each resolved field
+	replaced     []string              // Slice and map fields replaced as a whole
+	secrets      map[string]bool       // Paths of secret fields
+	mergePatch   bool                  // Whether nulls reset fields and maps are merged (RFC 7396)
+	env          *envLookup            // Environment variables for ${VAR} expansion
+	present      map[string]bool       // Paths of fields explicitly provided in the map
+	positions    positionIndex         // Source positions of the keys in the map, nil if unknown
+	keys         map[string]string     // Key paths of fields whose keys differ from their names
+	watches      []watchEvent          // Updated watched fields, reported after the update is committed
+}
+
+// newMapContext creates a new mapContext for one mapping pass reading values from source
+func newMapContext(option *Option, source Provenance) *mapContext {
+	return &mapContext{
+		option:     option,
+		byMap:      make(map[uintptr]*mapVisit),
+		source:     source,
+		provenance: make(map[string]Provenance),
+	}
+}
+
 // mapToStruct maps rawMap to struct using reflection
-func mapToStruct(rawMap map[string]any, target any, option *Option, isUpdate bool) error {
-	return mapToStructWithPath(rawMap, target, option, "", isUpdate, false)
+func mapToStruct(rawMap map[string]any, target any, ctx *mapContext, isUpdate bool) error {
+	return mapToStructWithPath(rawMap, target, ctx, "", isUpdate, false)
 }
 
 // mapToStructWithPath maps rawMap to struct with field path tracking
-func mapToStructWithPath(rawMap map[string]any, target any, option *Option, basePath string, isUpdate bool, parentOptional bool) error {
+func mapToStructWithPath(rawMap map[string]any, target any, ctx *mapContext, basePath string, isUpdate bool, parentOptional bool) (err error) {
 	v := reflect.ValueOf(target)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
 		return fmt.Errorf("target must be a pointer to struct")
@@ -22,6 +50,14 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 	v = v.Elem()
 	t := v.Type()
 
+	visit := ctx.visit(rawMap, basePath)
+
+	// Locate errors at the key of the field being mapped
+	var fieldPath string
+	defer func() {
+		err = ctx.locate(err, fieldPath)
+	}()
+
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
 		fieldType := t.Field(i)
@@ -32,7 +68,7 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 		}
 
 		// Parse tag
-		tagValue := fieldType.Tag.Get(option.TagName)
+		tagValue := fieldTag(t, fieldType, ctx.option)
 		tagInfo := parseTag(tagValue)
 
 		// Skip field if tag says so
@@ -47,33 +83,65 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 		}
 
 		// Build field path
-		fieldPath := fieldName
+		fieldPath = fieldName
 		if basePath != "" {
 			fieldPath = basePath + "." + fieldName
 		}
 
+		// Secret fields and everything below them are masked in output
+		secret := tagInfo.Secret || isSecretType(field.Type()) || ctx.isSecret(basePath)
+		if secret && !fieldType.Anonymous {
+			ctx.markSecret(fieldPath)
+		}
+
 		// Handle anonymous struct (embedded)
 		if fieldType.Anonymous {
 			if field.Kind() == reflect.Struct {
 				// Use current rawMap for anonymous struct
-				if err := mapToStructWithPath(rawMap, field.Addr().Interface(), option, basePath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
+				if err := mapToStructWithPath(rawMap, field.Addr().Interface(), ctx, basePath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
 					return err
 				}
 			}
 			continue
 		}
 
-		// Get value from map
-		value, exists := findValueInMap(rawMap, fieldName, option.MatchMode)
-		if !exists {
+		// Get value from map, falling back to deprecated aliases
+		visit.addField(fieldName)
+		var value, rawValue any
+		isDefault := false
+		key, exists, err := lookupFieldKey(fieldName, tagInfo, ctx, visit, basePath, fieldPath)
+		if err != nil {
+			return err
+		}
+		if exists {
+			ctx.recordKey(basePath, fieldPath, key)
+			value = rawMap[key]
+			rawValue = value
+
+			// In merge patch mode an explicit null resets the field to its default
+			if value == nil && isUpdate && ctx.mergePatch {
+				if err := resetField(field, tagInfo, ctx, fieldPath, fieldName, parentOptional, secret); err != nil {
+					return err
+				}
+				continue
+			}
+			if value != nil {
+				ctx.markPresent(fieldPath)
+			}
+		} else {
 			// For update mode, skip missing fields
 			if isUpdate {
 				continue
 			}
 
+			// Optional pointers with omitnil stay nil when their key is missing
+			if omitNil(field, tagInfo, ctx, parentOptional) {
+				continue
+			}
+
 			// Handle struct fields
-			if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
-				if err := handleStructField(field, fieldType, option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
+			if isStructType(field.Type()) {
+				if err := handleStructField(field, fieldType, ctx, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
 					return err
 				}
 				continue
@@ -81,12 +149,14 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 
 			// Use default value if available
 			if tagInfo.Default != "" {
-				processedDefault, err := processEnvVars(tagInfo.Default, option.UseEnv)
+				processedDefault, err := processEnvVars(tagInfo.Default, ctx.option.UseEnv, ctx.env)
 				if err != nil {
 					return fmt.Errorf("field %s default value error: %w", fieldPath, err)
 				}
 				value = processedDefault
+				rawValue = tagInfo.Default
 				exists = true
+				isDefault = true
 			} else {
 				// Check if field is required
 				if !tagInfo.Optional && !parentOptional {
@@ -97,36 +167,44 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 		}
 
 		// Process environment variables in value
-		processedValue, err := processEnvValue(value, option.UseEnv)
+		processedValue, err := processEnvValue(value, ctx.option.UseEnv, ctx.env)
 		if err != nil {
 			return fmt.Errorf("field %s environment variable error: %w", fieldPath, err)
 		}
 
+		// Decode unit values so they validate in their natural units
+		processedValue, err = decodeUnitValue(field.Type(), processedValue)
+		if err != nil {
+			return fmt.Errorf("field %s %w", fieldPath, err)
+		}
+
 		// Validate value
 		if err := validateValue(processedValue, tagInfo, fieldPath); err != nil {
+			if secret {
+				return fmt.Errorf("field %s secret value is invalid", fieldPath)
+			}
 			return err
 		}
 
-		// Handle watch callback for updates
-		if isUpdate && tagInfo.Watch && option.WatchCallback != nil {
-			oldValue := field.Interface()
-			if err := option.WatchCallback(fieldPath, fieldName, oldValue, processedValue); err != nil {
-				return fmt.Errorf("watch callback error for field %s: %w", fieldPath, err)
-			}
+		// Watched fields are reported once the update passed validation
+		if isUpdate && tagInfo.Watch && ctx.option.WatchCallback != nil {
+			ctx.recordWatch(fieldPath, fieldName, cloneValue(field).Interface(), processedValue, secret)
 		}
 
 		// Handle struct fields with value
-		if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct) {
-			if valueMap, ok := processedValue.(map[string]any); ok {
+		if isStructType(field.Type()) {
+			if valueMap, ok := toStringMap(processedValue); ok {
 				if field.Kind() == reflect.Ptr {
-					if field.IsNil() {
+					// Structs allocated by an update are mapped like a load so defaults apply
+					fresh := field.IsNil()
+					if fresh {
 						field.Set(reflect.New(field.Type().Elem()))
 					}
-					if err := mapToStructWithPath(valueMap, field.Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
+					if err := mapToStructWithPath(valueMap, field.Interface(), ctx, fieldPath, isUpdate && !fresh, parentOptional || tagInfo.Optional); err != nil {
 						return err
 					}
 				} else {
-					if err := mapToStructWithPath(valueMap, field.Addr().Interface(), option, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
+					if err := mapToStructWithPath(valueMap, field.Addr().Interface(), ctx, fieldPath, isUpdate, parentOptional || tagInfo.Optional); err != nil {
 						return err
 					}
 				}
@@ -135,9 +213,17 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 			}
 		} else {
 			// Set field value for non-struct types
-			if err := setFieldValue(field, processedValue, fieldPath); err != nil {
+			if err := setFieldValue(field, processedValue, ctx, fieldPath); err != nil {
+				if secret {
+					return fmt.Errorf("field %s secret value cannot be converted to %s", fieldPath, field.Type())
+				}
 				return err
 			}
+			ctx.recordOrigin(fieldPath, rawValue, processedValue, isDefault)
+			switch indirectType(field.Type()).Kind() {
+			case reflect.Slice, reflect.Map, reflect.Interface:
+				ctx.recordReplaced(fieldPath)
+			}
 		}
 	}
 
@@ -145,7 +231,7 @@ func mapToStructWithPath(rawMap map[string]any, target any, option *Option, base
 }
 
 // handleStructField handles struct and pointer to struct fields
-func handleStructField(field reflect.Value, fieldType reflect.StructField, option *Option, fieldPath string, isUpdate bool, parentOptional bool) error {
+func handleStructField(field reflect.Value, fieldType reflect.StructField, ctx *mapContext, fieldPath string, isUpdate bool, parentOptional bool) error {
 	if field.Kind() == reflect.Ptr {
 		// Handle pointer to struct
 		if field.IsNil() {
@@ -154,16 +240,16 @@ func handleStructField(field reflect.Value, fieldType reflect.StructField, optio
 			field.Set(newStruct)
 		}
 		// Process the struct that pointer points to
-		return mapToStructWithPath(make(map[string]any), field.Interface(), option, fieldPath, isUpdate, parentOptional)
+		return mapToStructWithPath(make(map[string]any), field.Interface(), ctx, fieldPath, isUpdate, parentOptional)
 	} else if field.Kind() == reflect.Struct {
 		// Handle direct struct
-		return mapToStructWithPath(make(map[string]any), field.Addr().Interface(), option, fieldPath, isUpdate, parentOptional)
+		return mapToStructWithPath(make(map[string]any), field.Addr().Interface(), ctx, fieldPath, isUpdate, parentOptional)
 	}
 	return nil
 }
 
 // setFieldValue sets field value with type conversion
-func setFieldValue(field reflect.Value, value any, fieldPath string) error {
+func setFieldValue(field reflect.Value, value any, ctx *mapContext, fieldPath string) error {
 	if value == nil {
 		return nil
 	}
@@ -181,20 +267,41 @@ func setFieldValue(field reflect.Value, value any, fieldPath string) error {
 		return nil
 	}
 
+	// Handle unit types (ByteSize, Rate)
+	if isUnitType(fieldType) && fieldType.Kind() != reflect.Ptr {
+		unitValue, err := decodeUnitValue(fieldType, value)
+		if err != nil {
+			return fmt.Errorf("field %s %w", fieldPath, err)
+		}
+		field.Set(reflect.ValueOf(unitValue))
+		return nil
+	}
+
 	// Handle pointer types
 	if fieldType.Kind() == reflect.Ptr {
 		if field.IsNil() {
 			field.Set(reflect.New(fieldType.Elem()))
 		}
-		return setFieldValue(field.Elem(), value, fieldPath)
+		return setFieldValue(field.Elem(), value, ctx, fieldPath)
+	}
+
+	// Feature flags accept a boolean shorthand
+	if fieldType == flagRuleType {
+		value = flagRuleValue(value)
 	}
 
-	// Handle struct types
-	if fieldType.Kind() == reflect.Struct {
-		if valueMap, ok := value.(map[string]any); ok {
-			// This should be handled by mapToStructWithPath
-			return fmt.Errorf("struct field %s should be handled by mapToStructWithPath, got map: %v", fieldPath, valueMap)
+	// Handle struct types, e.g. elements of []Struct or map[string]Struct
+	if isStructType(fieldType) {
+		valueMap, ok := toStringMap(value)
+		if !ok {
+			return fmt.Errorf("field %s expected map for struct, got %T", fieldPath, value)
 		}
+		return mapToStructWithPath(valueMap, field.Addr().Interface(), ctx, fieldPath, false, false)
+	}
+
+	// Handle interface types with registered concrete types
+	if isPolymorphicType(fieldType) {
+		return setPolymorphicValue(field, value, ctx, fieldPath)
 	}
 
 	// Direct assignment if types match
@@ -204,11 +311,11 @@ func setFieldValue(field reflect.Value, value any, fieldPath string) error {
 	}
 
 	// Type conversion
-	return convertAndSetValue(field, value, fieldPath)
+	return convertAndSetValue(field, value, ctx, fieldPath)
 }
 
 // convertAndSetValue converts value to field type and sets it
-func convertAndSetValue(field reflect.Value, value any, fieldPath string) error {
+func convertAndSetValue(field reflect.Value, value any, ctx *mapContext, fieldPath string) error {
 	fieldType := field.Type()
 	valueStr := fmt.Sprintf("%v", value)
 
@@ -254,10 +361,10 @@ func convertAndSetValue(field reflect.Value, value any, fieldPath string) error
 		}
 
 	case reflect.Slice:
-		return setSliceValue(field, value, fieldPath)
+		return setSliceValue(field, value, ctx, fieldPath)
 
 	case reflect.Map:
-		return setMapValue(field, value, fieldPath)
+		return setMapValue(field, value, ctx, fieldPath)
 
 	default:
 		return fmt.Errorf("field %s unsupported type conversion from %T to %s", fieldPath, value, fieldType.Kind())
@@ -267,20 +374,23 @@ func convertAndSetValue(field reflect.Value, value any, fieldPath string) error
 }
 
 // setSliceValue sets slice field value
-func setSliceValue(field reflect.Value, value any, fieldPath string) error {
-	valueSlice, ok := value.([]any)
-	if !ok {
+func setSliceValue(field reflect.Value, value any, ctx *mapContext, fieldPath string) error {
+	// Accept any slice type, TOML arrays of tables decode as []map[string]any
+	valueSlice := reflect.ValueOf(value)
+	if valueSlice.Kind() != reflect.Slice && valueSlice.Kind() != reflect.Array {
 		return fmt.Errorf("field %s expected slice, got %T", fieldPath, value)
 	}
 
 	sliceType := field.Type()
 
-	newSlice := reflect.MakeSlice(sliceType, len(valueSlice), len(valueSlice))
+	newSlice := reflect.MakeSlice(sliceType, valueSlice.Len(), valueSlice.Len())
 
-	for i, item := range valueSlice {
+	for i := 0; i < valueSlice.Len(); i++ {
 		elem := newSlice.Index(i)
-		if err := setFieldValue(elem, item, fmt.Sprintf("%s[%d]", fieldPath, i)); err != nil {
-			return err
+		item := valueSlice.Index(i).Interface()
+		elemPath := fmt.Sprintf("%s[%d]", fieldPath, i)
+		if err := setFieldValue(elem, item, ctx, elemPath); err != nil {
+			return ctx.locate(err, elemPath)
 		}
 	}
 
@@ -289,8 +399,8 @@ func setSliceValue(field reflect.Value, value any, fieldPath string) error {
 }
 
 // setMapValue sets map field value
-func setMapValue(field reflect.Value, value any, fieldPath string) error {
-	valueMap, ok := value.(map[string]any)
+func setMapValue(field reflect.Value, value any, ctx *mapContext, fieldPath string) error {
+	valueMap, ok := toStringMap(value)
 	if !ok {
 		return fmt.Errorf("field %s expected map, got %T", fieldPath, value)
 	}
@@ -299,19 +409,56 @@ func setMapValue(field reflect.Value, value any, fieldPath string) error {
 	keyType := mapType.Key()
 	valueType := mapType.Elem()
 
-	// Only support string keys for now
-	if keyType.Kind() != reflect.String {
-		return fmt.Errorf("field %s only string keys are supported for maps", fieldPath)
+	// Keys are strings in all formats, convert them to string, integer, float or bool keys
+	switch keyType.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+	default:
+		return fmt.Errorf("field %s unsupported map key type %s", fieldPath, keyType)
 	}
 
 	newMap := reflect.MakeMap(mapType)
 
+	// Merge patches keep existing entries, nulls delete them
+	if ctx.mergePatch && !field.IsNil() {
+		iter := field.MapRange()
+		for iter.Next() {
+			newMap.SetMapIndex(iter.Key(), iter.Value())
+		}
+	}
+
 	for k, v := range valueMap {
+		elemPath := fmt.Sprintf("%s[%s]", fieldPath, k)
+
+		mapKey := reflect.New(keyType).Elem()
+		if err := convertAndSetValue(mapKey, k, ctx, elemPath); err != nil {
+			return fmt.Errorf("field %s invalid map key %q: %w", fieldPath, k, err)
+		}
+
+		if ctx.mergePatch {
+			if v == nil {
+				newMap.SetMapIndex(mapKey, reflect.Value{})
+				continue
+			}
+			if existing := newMap.MapIndex(mapKey); existing.IsValid() && isStructType(valueType) {
+				if elemMap, ok := toStringMap(v); ok {
+					mapValue, err := mergeStructValue(existing, elemMap, ctx, elemPath)
+					if err != nil {
+						return err
+					}
+					newMap.SetMapIndex(mapKey, mapValue)
+					continue
+				}
+			}
+		}
+
 		mapValue := reflect.New(valueType).Elem()
-		if err := setFieldValue(mapValue, v, fmt.Sprintf("%s[%s]", fieldPath, k)); err != nil {
-			return err
+		if err := setFieldValue(mapValue, v, ctx, elemPath); err != nil {
+			return ctx.locate(err, elemPath)
 		}
-		newMap.SetMapIndex(reflect.ValueOf(k), mapValue)
+		newMap.SetMapIndex(mapKey, mapValue)
 	}
 
 	field.Set(newMap)
@@ -0,0 +1,91 @@
+package zcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type mapperUpstream struct {
+	Host   string `meta:"host"`
+	Weight int    `meta:"weight,default=1,range=[1:100]"`
+}
+
+type mapperShard struct {
+	Replicas int `meta:"replicas,default=3"`
+}
+
+type collectionConfig struct {
+	Upstreams []mapperUpstream          `meta:"upstreams"`
+	Backups   []*mapperUpstream         `meta:"backups,optional"`
+	Pools     map[string]mapperUpstream `meta:"pools,optional"`
+	Shards    map[int]mapperShard       `meta:"shards,optional"`
+	Features  map[bool]string           `meta:"features,optional"`
+}
+
+func TestSlicesAndMapsOfStructs(t *testing.T) {
+	_, target := newTestConfig[collectionConfig](t, map[string]any{
+		"upstreams": []any{map[string]any{"host": "a", "weight": 5}, map[string]any{"host": "b"}},
+		"backups":   []any{map[string]any{"host": "c"}},
+		"pools":     map[string]any{"main": map[string]any{"host": "d"}},
+		"shards":    map[string]any{"1": map[string]any{}, "2": map[string]any{"replicas": 5}},
+		"features":  map[string]any{"true": "on", "false": "off"},
+	})
+
+	if len(target.Upstreams) != 2 || target.Upstreams[0] != (mapperUpstream{Host: "a", Weight: 5}) || target.Upstreams[1].Weight != 1 {
+		t.Fatalf("unexpected upstreams: %+v", target.Upstreams)
+	}
+	if len(target.Backups) != 1 || target.Backups[0] == nil || *target.Backups[0] != (mapperUpstream{Host: "c", Weight: 1}) {
+		t.Fatalf("unexpected backups: %+v", target.Backups)
+	}
+	if target.Pools["main"] != (mapperUpstream{Host: "d", Weight: 1}) {
+		t.Fatalf("unexpected pools: %+v", target.Pools)
+	}
+	if target.Shards[1].Replicas != 3 || target.Shards[2].Replicas != 5 {
+		t.Fatalf("unexpected shards: %+v", target.Shards)
+	}
+	if target.Features[true] != "on" || target.Features[false] != "off" {
+		t.Fatalf("unexpected features: %+v", target.Features)
+	}
+}
+
+func TestSlicesAndMapsOfStructsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  map[string]any
+		err  string
+	}{
+		{
+			name: "element validation",
+			raw:  map[string]any{"upstreams": []any{map[string]any{"host": "a"}, map[string]any{"host": "b", "weight": 500}}},
+			err:  "upstreams[1].weight",
+		},
+		{
+			name: "element required",
+			raw:  map[string]any{"upstreams": []any{map[string]any{"weight": 2}}},
+			err:  "upstreams[0].host is required",
+		},
+		{
+			name: "map value",
+			raw:  map[string]any{"upstreams": []any{}, "pools": map[string]any{"main": map[string]any{"weight": 2}}},
+			err:  "pools[main].host is required",
+		},
+		{
+			name: "integer key",
+			raw:  map[string]any{"upstreams": []any{}, "shards": map[string]any{"one": map[string]any{}}},
+			err:  `invalid map key "one"`,
+		},
+		{
+			name: "element type",
+			raw:  map[string]any{"upstreams": []any{"a"}},
+			err:  "upstreams[0]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestConfig[collectionConfig](tt.raw)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
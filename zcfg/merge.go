@@ -0,0 +1,256 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MergeMaps deep merges src over dst and returns the result, inputs are not modified.
+// Nested maps are merged key by key, all other values including slices are replaced.
+func MergeMaps(dst, src map[string]any) map[string]any {
+	result := copyMap(dst)
+	for key, srcValue := range src {
+		srcMap, srcIsMap := toStringMap(srcValue)
+		dstMap, dstIsMap := toStringMap(result[key])
+		if srcIsMap && dstIsMap {
+			result[key] = MergeMaps(dstMap, srcMap)
+		} else {
+			result[key] = copyAny(srcValue)
+		}
+	}
+	return result
+}
+
+// copyMap returns a deep copy of map m
+func copyMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		result[key] = copyAny(value)
+	}
+	return result
+}
+
+// copyAny returns a deep copy of maps and slices in a raw value
+func copyAny(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyMap(v)
+	case map[any]any:
+		m, _ := toStringMap(v)
+		return copyMap(m)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyAny(item)
+		}
+		return result
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyMap(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// ExpandEnv returns a copy of m with ${VAR} and ${VAR:default} expanded in all string values
+func ExpandEnv(m map[string]any) (map[string]any, error) {
+	result, err := expandEnvValue(m, "")
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]any), nil
+}
+
+// expandEnvValue expands environment variables in value recursively with path tracking
+func expandEnvValue(value any, path string) (any, error) {
+	switch v := value.(type) {
+	case string:
+		expanded, err := processEnvVars(v, true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("field %s environment variable error: %w", path, err)
+		}
+		return expanded, nil
+	case map[string]any, map[any]any:
+		m, _ := toStringMap(v)
+		result := make(map[string]any, len(m))
+		for key, item := range m {
+			expanded, err := expandEnvValue(item, joinPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+			result[key] = expanded
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := expandEnvValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := expandEnvValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// ChangeKind describes how a value differs between two configs
+type ChangeKind int
+
+const (
+	ChangeAdded    ChangeKind = iota // Key only present in the new config
+	ChangeRemoved                    // Key only present in the old config
+	ChangeModified                   // Key present in both with different values
+)
+
+// String returns the change kind name
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k ChangeKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Change describes a difference between two configs
+type Change struct {
+	Path string     `json:"path"`          // Key path, e.g. server.port or upstreams[1].host
+	Kind ChangeKind `json:"kind"`          // Kind of change
+	Old  any        `json:"old,omitempty"` // Old value, nil if added
+	New  any        `json:"new,omitempty"` // New value, nil if removed
+}
+
+// String returns the change in diff notation
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %v", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %v", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s: %v -> %v", c.Path, c.Old, c.New)
+	}
+}
+
+// Diff compares two raw maps semantically and returns the changes sorted by path.
+// Key order is ignored and numbers compare by value, so 8080 equals 8080.0.
+func Diff(oldMap, newMap map[string]any) []Change {
+	var changes []Change
+	diffValues(oldMap, newMap, "", &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// diffValues appends changes between old and new values at path
+func diffValues(oldValue, newValue any, path string, changes *[]Change) {
+	oldMap, oldIsMap := toStringMap(oldValue)
+	newMap, newIsMap := toStringMap(newValue)
+	if oldIsMap && newIsMap {
+		for key, oldItem := range oldMap {
+			if newItem, exists := newMap[key]; exists {
+				diffValues(oldItem, newItem, joinPath(path, key), changes)
+			} else {
+				*changes = append(*changes, Change{Path: joinPath(path, key), Kind: ChangeRemoved, Old: oldItem})
+			}
+		}
+		for key, newItem := range newMap {
+			if _, exists := oldMap[key]; !exists {
+				*changes = append(*changes, Change{Path: joinPath(path, key), Kind: ChangeAdded, New: newItem})
+			}
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := toSlice(oldValue)
+	newSlice, newIsSlice := toSlice(newValue)
+	if oldIsSlice && newIsSlice {
+		for i := 0; i < max(len(oldSlice), len(newSlice)); i++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(newSlice):
+				*changes = append(*changes, Change{Path: itemPath, Kind: ChangeRemoved, Old: oldSlice[i]})
+			case i >= len(oldSlice):
+				*changes = append(*changes, Change{Path: itemPath, Kind: ChangeAdded, New: newSlice[i]})
+			default:
+				diffValues(oldSlice[i], newSlice[i], itemPath, changes)
+			}
+		}
+		return
+	}
+
+	if !valuesEqual(oldValue, newValue) {
+		*changes = append(*changes, Change{Path: path, Kind: ChangeModified, Old: oldValue, New: newValue})
+	}
+}
+
+// toSlice converts any slice value to []any
+func toSlice(value any) ([]any, bool) {
+	if s, ok := value.([]any); ok {
+		return s, true
+	}
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.Kind() != reflect.Slice {
+		return nil, false
+	}
+	result := make([]any, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result, true
+}
+
+// valuesEqual compares scalar values, numbers are compared by value regardless of type
+func valuesEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toFloat converts numeric values to float64
+func toFloat(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return 0, false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -1,26 +1,59 @@
 package zcfg
 
+import (
+	"text/template"
+
+	"github.com/meta-apex/zenith/zlog"
+)
+
 // MatchMode represents field name matching mode
 type MatchMode int
 
 const (
-	MatchNormal     MatchMode = iota // Normal matching
-	MatchIgnoreCase                  // Case insensitive matching
-	MatchCamelCase                   // Camel case matching
-	MatchSnakeCase                   // Snake case matching
+	MatchNormal             MatchMode = iota // Normal matching
+	MatchIgnoreCase                          // Case insensitive matching
+	MatchCamelCase                           // Camel case matching
+	MatchSnakeCase                           // Snake case matching
+	MatchKebabCase                           // Kebab case matching, e.g. max-conns
+	MatchScreamingSnakeCase                  // Screaming snake case matching, e.g. MAX_CONNS
+	MatchFuzzy                               // Case, underscores and dashes are ignored, e.g. max_conns, maxConns and max-conns match
 )
 
-// WatchCallback is the callback function for field changes
+// KeyMatcher normalizes field names and map keys, a key matches a field if both normalize to the same string
+type KeyMatcher func(name string) string
+
+// WatchCallback is the callback function for field changes. It is called with the raw new value
+// once an update passed validation, before it is committed. An error rejects the update.
 type WatchCallback func(path, key string, oldValue, newValue any) error
 
 // Option represents configuration options
 type Option struct {
-	TagName       string        // Tag name, default "meta"
-	MatchMode     MatchMode     // Field matching mode
-	UseEnv        bool          // Whether to use environment variables
-	Updatable     bool          // Whether to support updates
-	HotReload     bool          // Whether to enable hot reload
-	WatchCallback WatchCallback // Watch callback function
+	TagName         string           // Tag name, default "meta"
+	MatchMode       MatchMode        // Field matching mode
+	Matcher         KeyMatcher       // Custom key matcher, overrides MatchMode if set
+	UseEnv          bool             // Whether to use environment variables
+	Updatable       bool             // Whether to support updates
+	HotReload       bool             // Whether to enable hot reload
+	WatchCallback   WatchCallback    // Watch callback function
+	TypeKey         string           // Discriminator key for polymorphic fields, default "type"
+	Name            string           // Instance name, allows several configs of the same type
+	Registry        *Registry        // Registry to register the config in, default DefaultRegistry()
+	Strict          bool             // Whether unknown keys are an error instead of a warning
+	Logger          *zlog.Logger     // Logger for warnings, default zlog default logger
+	HistorySize     int              // Number of snapshots kept for History and RollbackTo, 0 disables history
+	Overlays        []string         // Files merged over the loaded config in order, missing files are skipped
+	EnvFiles        []string         // Env files read when UseEnv is set, later files take precedence, default .env
+	EnvOverride     bool             // Whether env file variables take precedence over the process environment
+	EnvExport       bool             // Whether env file variables are also set in the process environment, default true
+	Profile         string           // Active profile, default read from ProfileEnv
+	ProfileEnv      string           // Environment variable selecting the profile, default "APP_PROFILE"
+	ProfileKey      string           // Key of the in-file profiles section, default "profiles", unused if the struct has a field for it
+	OmitNil         bool             // Whether all optional pointer fields stay nil when their key is missing, like the omitnil tag option
+	Templates       bool             // Whether string values are rendered with text/template before mapping
+	TemplateFuncs   template.FuncMap // Additional template functions, added to the built-in functions
+	TemplateDir     string           // Directory the file template function reads from, empty disables it
+	StaticPolicy    StaticPolicy     // How updates changing static fields are handled, default StaticReject
+	RestartCallback RestartCallback  // Callback for static field changes of updates
 }
 
 // NewOption creates a new Option with default values
@@ -32,6 +65,13 @@ func NewOption() *Option {
 		Updatable:     false,
 		HotReload:     false,
 		WatchCallback: nil,
+		TypeKey:       "type",
+		HistorySize:   10,
+		EnvFiles:      []string{".env"},
+		EnvExport:     true,
+		ProfileEnv:    "APP_PROFILE",
+		ProfileKey:    "profiles",
+		StaticPolicy:  StaticReject,
 	}
 }
 
@@ -49,6 +89,13 @@ func WithMatchMode(mode MatchMode) func(*Option) {
 	}
 }
 
+// WithMatcher sets a custom key matcher that overrides the match mode
+func WithMatcher(matcher KeyMatcher) func(*Option) {
+	return func(o *Option) {
+		o.Matcher = matcher
+	}
+}
+
 // WithUseEnv sets whether to use environment variables
 func WithUseEnv(useEnv bool) func(*Option) {
 	return func(o *Option) {
@@ -76,3 +123,171 @@ func WithWatchCallback(callback WatchCallback) func(*Option) {
 		o.WatchCallback = callback
 	}
 }
+
+// WithTypeKey sets the discriminator key used to select concrete types for interface fields
+func WithTypeKey(key string) func(*Option) {
+	return func(o *Option) {
+		o.TypeKey = key
+	}
+}
+
+// WithName sets the instance name used to register the config
+func WithName(name string) func(*Option) {
+	return func(o *Option) {
+		o.Name = name
+	}
+}
+
+// WithRegistry sets the registry the config is registered in
+func WithRegistry(registry *Registry) func(*Option) {
+	return func(o *Option) {
+		o.Registry = registry
+	}
+}
+
+// WithStrict sets whether unknown keys cause an error
+func WithStrict(strict bool) func(*Option) {
+	return func(o *Option) {
+		o.Strict = strict
+	}
+}
+
+// WithLogger sets the logger used for warnings such as deprecated keys
+func WithLogger(logger *zlog.Logger) func(*Option) {
+	return func(o *Option) {
+		o.Logger = logger
+	}
+}
+
+// WithHistorySize sets the number of snapshots kept for History and RollbackTo
+func WithHistorySize(size int) func(*Option) {
+	return func(o *Option) {
+		o.HistorySize = size
+	}
+}
+
+// WithOverlay adds files on the real filesystem that are deep merged over the loaded config,
+// e.g. a local file overriding defaults embedded with LoadFS. Missing files are skipped.
+func WithOverlay(files ...string) func(*Option) {
+	return func(o *Option) {
+		o.Overlays = append(o.Overlays, files...)
+	}
+}
+
+// WithEnvFiles sets the env files read when UseEnv is set, later files take precedence.
+// Names may reference environment variables, e.g. WithEnvFiles(".env", ".env.local", ".env.${APP_ENV}").
+func WithEnvFiles(files ...string) func(*Option) {
+	return func(o *Option) {
+		o.EnvFiles = files
+	}
+}
+
+// WithEnvOverride sets whether env file variables take precedence over the process environment
+func WithEnvOverride(override bool) func(*Option) {
+	return func(o *Option) {
+		o.EnvOverride = override
+	}
+}
+
+// WithEnvExport sets whether env file variables are also set in the process environment.
+// Variables already set in the process are only replaced with EnvOverride.
+func WithEnvExport(export bool) func(*Option) {
+	return func(o *Option) {
+		o.EnvExport = export
+	}
+}
+
+// WithProfile sets the active profile, overriding the profile environment variable
+func WithProfile(profile string) func(*Option) {
+	return func(o *Option) {
+		o.Profile = profile
+	}
+}
+
+// WithProfileEnv sets the environment variable selecting the profile
+func WithProfileEnv(name string) func(*Option) {
+	return func(o *Option) {
+		o.ProfileEnv = name
+	}
+}
+
+// WithProfileKey sets the key of the in-file profiles section, an empty key disables the section.
+// If the config struct has a field for the key, the key is mapped to the field instead.
+func WithProfileKey(key string) func(*Option) {
+	return func(o *Option) {
+		o.ProfileKey = key
+	}
+}
+
+// WithOmitNil sets whether all optional pointer fields stay nil when their key is missing
+func WithOmitNil(omitNil bool) func(*Option) {
+	return func(o *Option) {
+		o.OmitNil = omitNil
+	}
+}
+
+// WithTemplates sets whether string values are rendered with text/template before mapping
+func WithTemplates(enabled bool) func(*Option) {
+	return func(o *Option) {
+		o.Templates = enabled
+	}
+}
+
+// WithTemplateFuncs adds template functions, they replace built-in functions with the same name
+func WithTemplateFuncs(funcs template.FuncMap) func(*Option) {
+	return func(o *Option) {
+		if o.TemplateFuncs == nil {
+			o.TemplateFuncs = make(template.FuncMap)
+		}
+		for name, fn := range funcs {
+			o.TemplateFuncs[name] = fn
+		}
+	}
+}
+
+// WithTemplateDir sets the directory the file template function reads from.
+// Paths are relative to dir and may not escape it, so remote configs can't read other files.
+func WithTemplateDir(dir string) func(*Option) {
+	return func(o *Option) {
+		o.TemplateDir = dir
+	}
+}
+
+// logger returns the configured logger or the zlog default logger
+func (o *Option) logger() *zlog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return zlog.GetDefaultLogger()
+}
+
+// registry returns the configured registry or the default registry
+func (o *Option) registry() *Registry {
+	if o.Registry != nil {
+		return o.Registry
+	}
+	return defaultRegistry
+}
+
+// typeKey returns the discriminator key for polymorphic fields
+func (o *Option) typeKey() string {
+	if o.TypeKey == "" {
+		return "type"
+	}
+	return o.TypeKey
+}
+
+// WithStaticPolicy sets how updates and reloads changing static fields are handled
+func WithStaticPolicy(policy StaticPolicy) func(*Option) {
+	return func(o *Option) {
+		o.StaticPolicy = policy
+	}
+}
+
+// WithRestartCallback sets the callback for static field changes of updates.
+// It is called while the config is locked, like the watch callback.
+func WithRestartCallback(callback RestartCallback) func(*Option) {
+	return func(o *Option) {
+		o.RestartCallback = callback
+	}
+}
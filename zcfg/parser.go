@@ -1,8 +1,12 @@
 package zcfg
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,23 +15,133 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// parseConfigFile parses configuration file and returns rawMap
-func parseConfigFile(filename string) (map[string]any, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
-	}
+// Format represents a configuration file format
+type Format string
 
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// FormatFromExt returns the format for a file name based on its extension
+func FormatFromExt(filename string) (Format, error) {
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
 	case ".json":
-		return parseJSON(data)
+		return FormatJSON, nil
 	case ".yaml", ".yml":
-		return parseYAML(data)
+		return FormatYAML, nil
 	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported config file format: %s", ext)
+	}
+}
+
+// ParseFile parses configuration file into a raw map, the format is chosen by extension
+func ParseFile(filename string) (map[string]any, error) {
+	return parseConfigFile(filename)
+}
+
+// ParseFS parses configuration file name from fsys into a raw map, the format is chosen by extension
+func ParseFS(fsys fs.FS, name string) (map[string]any, error) {
+	rawMap, _, err := readConfigFile(fsys, name)
+	return rawMap, err
+}
+
+// ParseBytes parses configuration content in the given format into a raw map
+func ParseBytes(data []byte, format Format) (map[string]any, error) {
+	switch format {
+	case FormatJSON:
+		return parseJSON(data)
+	case FormatYAML:
+		return parseYAML(data)
+	case FormatTOML:
 		return parseTOML(data)
 	default:
-		return nil, fmt.Errorf("unsupported config file format: %s", ext)
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+// Marshal encodes a raw map in the given format
+func Marshal(m map[string]any, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	case FormatYAML:
+		data, err := yaml.Marshal(m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return data, nil
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(m); err != nil {
+			return nil, fmt.Errorf("failed to encode TOML: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+// parseConfigFile parses configuration file and returns rawMap
+func parseConfigFile(filename string) (map[string]any, error) {
+	rawMap, _, err := readConfigFile(nil, filename)
+	return rawMap, err
+}
+
+// readConfigFile parses configuration file name from fsys, or the real filesystem if fsys is nil,
+// and returns rawMap with the positions of its keys
+func readConfigFile(fsys fs.FS, name string) (map[string]any, positionIndex, error) {
+	var data []byte
+	var err error
+	if fsys != nil {
+		data, err = fs.ReadFile(fsys, name)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file %s: %w", name, err)
+	}
+
+	format, err := FormatFromExt(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseSource(data, format, name)
+}
+
+// parseSource parses content in the given format and returns rawMap with the positions of its keys.
+// Positions are tracked for YAML and TOML, other formats only record file for their keys if it is set.
+// file names the source in positions.
+// The documents of a multi-document YAML stream are merged in order.
+func parseSource(data []byte, format Format, file string) (map[string]any, positionIndex, error) {
+	switch format {
+	case FormatYAML:
+		docs, positions, err := parseYAMLDocuments(data, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawMap, merged := mergeDocuments(docs, positions)
+		return rawMap, merged, nil
+	case FormatTOML:
+		rawMap, err := parseTOML(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return rawMap, parseTOMLPositions(data, file), nil
+	default:
+		rawMap, err := ParseBytes(data, format)
+		if err != nil || file == "" {
+			return rawMap, nil, err
+		}
+		return rawMap, filePositions(rawMap, file), nil
 	}
 }
 
@@ -40,15 +154,86 @@ func parseJSON(data []byte) (map[string]any, error) {
 	return rawMap, nil
 }
 
-// parseYAML parses YAML data and returns rawMap
+// parseYAML parses YAML data and returns rawMap, the documents of a multi-document stream are merged in order
 func parseYAML(data []byte) (map[string]any, error) {
-	var rawMap map[string]any
-	if err := yaml.Unmarshal(data, &rawMap); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	docs, _, err := parseYAMLDocuments(data, "")
+	if err != nil {
+		return nil, err
 	}
+	rawMap, _ := mergeDocuments(docs, nil)
 	return rawMap, nil
 }
 
+// parseYAMLDocuments parses each document of a YAML stream separated by ---. Empty documents
+// are kept as nil maps so indices match the stream, trailing empty documents are dropped.
+func parseYAMLDocuments(data []byte, file string) ([]map[string]any, []positionIndex, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var docs []map[string]any
+	var positions []positionIndex
+	for index := 0; ; index++ {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+
+		var rawMap map[string]any
+		if err := node.Decode(&rawMap); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML document %d: %w", index, err)
+		}
+		var docPositions positionIndex
+		if rawMap != nil {
+			docPositions = parseYAMLPositions(&node, file)
+		}
+		docs = append(docs, rawMap)
+		positions = append(positions, docPositions)
+	}
+
+	for len(docs) > 0 && docs[len(docs)-1] == nil {
+		docs, positions = docs[:len(docs)-1], positions[:len(positions)-1]
+	}
+	return docs, positions, nil
+}
+
+// mergeDocuments deep merges documents and their positions in order, later documents take precedence
+func mergeDocuments(docs []map[string]any, positions []positionIndex) (map[string]any, positionIndex) {
+	var rawMap map[string]any
+	var merged positionIndex
+	for i, doc := range docs {
+		// Empty documents are placeholders
+		if doc == nil {
+			continue
+		}
+		if rawMap == nil {
+			rawMap = doc
+		} else {
+			rawMap = MergeMaps(rawMap, doc)
+		}
+		if i < len(positions) {
+			merged = merged.merge(doc, positions[i])
+		}
+	}
+	return rawMap, merged
+}
+
+// parseDocuments parses content in the given format into one raw map per document with the
+// positions of its keys. Only YAML streams hold several documents.
+func parseDocuments(data []byte, format Format, file string) ([]map[string]any, []positionIndex, error) {
+	if format == FormatYAML {
+		return parseYAMLDocuments(data, file)
+	}
+
+	rawMap, positions, err := parseSource(data, format, file)
+	if err != nil || rawMap == nil {
+		return nil, nil, err
+	}
+	return []map[string]any{rawMap}, []positionIndex{positions}, nil
+}
+
 // parseTOML parses TOML data and returns rawMap
 func parseTOML(data []byte) (map[string]any, error) {
 	var rawMap map[string]any
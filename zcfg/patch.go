@@ -0,0 +1,400 @@
+package zcfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to the configuration.
+// Present keys are updated like Update, null resets a field to its default or zero
+// value and deletes a map entry. Validation, hooks and watch callbacks run as for Update.
+func (c *Config) ApplyMergePatch(patch []byte) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
+	}
+
+	m, err := parseJSONBytes(patch)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return fmt.Errorf("merge patch must be a JSON object")
+	}
+
+	return c.update(m, Provenance{Kind: SourceUpdate, Time: time.Now()}, true)
+}
+
+// PatchOperation is a single JSON Patch (RFC 6902) operation
+type PatchOperation struct {
+	Op    string          `json:"op"`              // add, remove, replace or test
+	Path  string          `json:"path"`            // JSON Pointer, e.g. /server/port or /upstreams/0
+	Value json.RawMessage `json:"value,omitempty"` // Value for add, replace and test
+}
+
+// ApplyJSONPatch applies a JSON Patch (RFC 6902) to the configuration.
+// Supported operations are add, remove, replace and test. The operations are applied
+// to the effective configuration and the result goes through the same validation,
+// hooks and watch callbacks as Update. Either all operations apply or none.
+// Test operations see secret fields masked, like the redacted views.
+func (c *Config) ApplyJSONPatch(patch []byte) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
+	}
+
+	var ops []PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("failed to parse JSON patch: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	before, _ := redactValue(reflect.ValueOf(c.target), c.option, false, nil).(map[string]any)
+
+	// Tests see secret values masked, so they cannot be used to guess secrets
+	targetType := reflect.TypeOf(c.target)
+	secretKeys := func(doc any) map[string]bool {
+		m, _ := toStringMap(doc)
+		return secretKeySet(targetType, m, c.option)
+	}
+
+	var doc any = copyMap(before)
+	for i, op := range ops {
+		var err error
+		doc, err = applyPatchOperation(doc, op, secretKeys)
+		if err != nil {
+			return fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	after, ok := doc.(map[string]any)
+	if !ok {
+		return fmt.Errorf("patch result must be a JSON object, got %T", doc)
+	}
+
+	mergePatch := createMergePatch(before, after)
+	if len(mergePatch) == 0 {
+		return nil
+	}
+	return c.apply(mergePatch, nil, Provenance{Kind: SourceUpdate, Time: time.Now()}, true)
+}
+
+// resetField resets a field to its default or zero value for a null in a merge patch
+func resetField(field reflect.Value, tagInfo *TagInfo, ctx *mapContext, fieldPath, fieldName string, parentOptional, secret bool) error {
+	watched := tagInfo.Watch && ctx.option.WatchCallback != nil
+	var oldValue any
+	if watched {
+		oldValue = cloneValue(field).Interface()
+	}
+	field.Set(reflect.Zero(field.Type()))
+	ctx.recordReplaced(fieldPath)
+
+	switch {
+	case omitNil(field, tagInfo, ctx, parentOptional):
+		// Optional pointers with omitnil are reset to nil
+	case isStructType(field.Type()):
+		// Nested structs are rebuilt from their defaults
+		if err := handleStructField(field, reflect.StructField{}, ctx, fieldPath, false, parentOptional || tagInfo.Optional); err != nil {
+			return err
+		}
+	case tagInfo.Default != "":
+		processedDefault, err := processEnvVars(tagInfo.Default, ctx.option.UseEnv, ctx.env)
+		if err != nil {
+			return fmt.Errorf("field %s default value error: %w", fieldPath, err)
+		}
+		value, err := decodeUnitValue(field.Type(), processedDefault)
+		if err != nil {
+			return fmt.Errorf("field %s %w", fieldPath, err)
+		}
+		if err := validateValue(value, tagInfo, fieldPath); err != nil {
+			return err
+		}
+		if err := setFieldValue(field, value, ctx, fieldPath); err != nil {
+			return err
+		}
+		ctx.recordOrigin(fieldPath, tagInfo.Default, value, true)
+	case !tagInfo.Optional && !parentOptional:
+		return fmt.Errorf("field %s is required and cannot be reset", fieldPath)
+	}
+
+	if watched {
+		ctx.recordWatch(fieldPath, fieldName, oldValue, field.Interface(), secret)
+	}
+	return nil
+}
+
+// omitNil checks if pointer field stays nil when its key is missing. Required pointers
+// are still allocated or reported missing, omitnil only applies to optional fields.
+func omitNil(field reflect.Value, tagInfo *TagInfo, ctx *mapContext, parentOptional bool) bool {
+	return field.Kind() == reflect.Ptr && (tagInfo.OmitNil || ctx.option.OmitNil) && (tagInfo.Optional || parentOptional)
+}
+
+// mergeStructValue merges m into a copy of the struct or struct pointer value existing
+func mergeStructValue(existing reflect.Value, m map[string]any, ctx *mapContext, fieldPath string) (reflect.Value, error) {
+	merged := cloneValue(existing)
+
+	target := merged
+	if merged.Kind() == reflect.Ptr {
+		if merged.IsNil() {
+			target = reflect.New(merged.Type().Elem())
+		}
+	} else {
+		target = reflect.New(merged.Type())
+		target.Elem().Set(merged)
+	}
+
+	if err := mapToStructWithPath(m, target.Interface(), ctx, fieldPath, true, false); err != nil {
+		return reflect.Value{}, err
+	}
+
+	if existing.Kind() == reflect.Ptr {
+		return target, nil
+	}
+	return target.Elem(), nil
+}
+
+// createMergePatch returns the merge patch that turns before into after
+func createMergePatch(before, after map[string]any) map[string]any {
+	patch := make(map[string]any)
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			patch[key] = nil
+		}
+	}
+
+	for key, afterValue := range after {
+		beforeValue, exists := before[key]
+		if !exists {
+			patch[key] = afterValue
+			continue
+		}
+
+		beforeMap, beforeIsMap := toStringMap(beforeValue)
+		afterMap, afterIsMap := toStringMap(afterValue)
+		if beforeIsMap && afterIsMap {
+			if nested := createMergePatch(beforeMap, afterMap); len(nested) > 0 {
+				patch[key] = nested
+			}
+			continue
+		}
+
+		if !rawEqual(beforeValue, afterValue) {
+			patch[key] = afterValue
+		}
+	}
+	return patch
+}
+
+// rawEqual compares raw values semantically like Diff
+func rawEqual(a, b any) bool {
+	var changes []Change
+	diffValues(a, b, "", &changes)
+	return len(changes) == 0
+}
+
+// applyPatchOperation applies op to doc and returns the resulting document.
+// Test operations compare against the document with the values at secretKeys masked.
+func applyPatchOperation(doc any, op PatchOperation, secretKeys func(doc any) map[string]bool) (any, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("missing value")
+		}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	case "remove":
+	default:
+		return nil, fmt.Errorf("unsupported operation '%s'", op.Op)
+	}
+
+	if op.Op == "test" {
+		current, err := getPointer(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		if secrets := secretKeys(doc); len(secrets) > 0 {
+			path := pointerPath(doc, tokens)
+			if isSecretKeyPath(path, secrets) {
+				current = RedactedValue
+			} else {
+				current = maskSecretKeys(current, path, secrets)
+			}
+		}
+		if !rawEqual(current, value) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	}
+
+	// The root can only be replaced as a whole
+	if len(tokens) == 0 {
+		if op.Op == "remove" {
+			return nil, fmt.Errorf("cannot remove the root")
+		}
+		return value, nil
+	}
+
+	return updatePointer(doc, tokens, func(parent any, token string) (any, error) {
+		switch op.Op {
+		case "add":
+			return addValue(parent, token, value)
+		case "replace":
+			return replaceValue(parent, token, value)
+		default:
+			return removeValue(parent, token)
+		}
+	})
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+// pointerPath returns the key path of the value referenced by tokens, e.g. upstreams[0].host
+func pointerPath(doc any, tokens []string) string {
+	var path string
+	current := doc
+	for _, token := range tokens {
+		if _, isList := toSlice(current); isList {
+			path = fmt.Sprintf("%s[%s]", path, token)
+		} else {
+			path = joinPath(path, token)
+		}
+		current, _ = childValue(current, token)
+	}
+	return path
+}
+
+// getPointer returns the value referenced by tokens
+func getPointer(doc any, tokens []string) (any, error) {
+	current := doc
+	for _, token := range tokens {
+		child, err := childValue(current, token)
+		if err != nil {
+			return nil, err
+		}
+		current = child
+	}
+	return current, nil
+}
+
+// updatePointer applies fn to the parent of the value referenced by tokens and
+// stores the updated containers back along the path
+func updatePointer(doc any, tokens []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	child, err := childValue(doc, tokens[0])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updatePointer(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	return replaceValue(doc, tokens[0], child)
+}
+
+// childValue returns the member or element token of container
+func childValue(container any, token string) (any, error) {
+	if m, ok := container.(map[string]any); ok {
+		value, exists := m[token]
+		if !exists {
+			return nil, fmt.Errorf("path member '%s' not found", token)
+		}
+		return value, nil
+	}
+	if s, ok := container.([]any); ok {
+		index, err := arrayIndex(token, len(s)-1)
+		if err != nil {
+			return nil, err
+		}
+		return s[index], nil
+	}
+	return nil, fmt.Errorf("cannot reference '%s' in %T", token, container)
+}
+
+// addValue adds value as member or element token of container
+func addValue(container any, token string, value any) (any, error) {
+	if m, ok := container.(map[string]any); ok {
+		m[token] = value
+		return m, nil
+	}
+	if s, ok := container.([]any); ok {
+		if token == "-" {
+			return append(s, value), nil
+		}
+		index, err := arrayIndex(token, len(s))
+		if err != nil {
+			return nil, err
+		}
+		s = append(s[:index], append([]any{value}, s[index:]...)...)
+		return s, nil
+	}
+	return nil, fmt.Errorf("cannot add '%s' to %T", token, container)
+}
+
+// replaceValue replaces the existing member or element token of container
+func replaceValue(container any, token string, value any) (any, error) {
+	if _, err := childValue(container, token); err != nil {
+		return nil, err
+	}
+	if m, ok := container.(map[string]any); ok {
+		m[token] = value
+		return m, nil
+	}
+	s := container.([]any)
+	index, _ := arrayIndex(token, len(s)-1)
+	s[index] = value
+	return s, nil
+}
+
+// removeValue removes the existing member or element token of container
+func removeValue(container any, token string) (any, error) {
+	if _, err := childValue(container, token); err != nil {
+		return nil, err
+	}
+	if m, ok := container.(map[string]any); ok {
+		delete(m, token)
+		return m, nil
+	}
+	s := container.([]any)
+	index, _ := arrayIndex(token, len(s)-1)
+	return append(s[:index:index], s[index+1:]...), nil
+}
+
+// arrayIndex parses an array index token and checks it is within [0, maxIndex]
+func arrayIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	if index > maxIndex {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
@@ -0,0 +1,138 @@
+package zcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type patchStore interface{ kind() string }
+
+type patchRedis struct {
+	Addr string `meta:"addr"`
+	DB   int    `meta:"db,default=0"`
+}
+
+func (patchRedis) kind() string { return "redis" }
+
+type patchConfig struct {
+	Port    int               `meta:"port"`
+	Level   string            `meta:"level,default=info"`
+	Tags    map[string]string `meta:"tags,optional"`
+	Hosts   []string          `meta:"hosts,optional"`
+	Token   string            `meta:"token,secret,optional"`
+	Store   patchStore        `meta:"store,optional"`
+	Timeout int               `meta:"timeout,optional"`
+}
+
+func init() {
+	MustRegisterType[patchStore, patchRedis]("patch-redis")
+}
+
+func newPatchConfig(t *testing.T) (*Config, *patchConfig) {
+	t.Helper()
+	config, target := newTestConfig[patchConfig](t, map[string]any{
+		"port":  80,
+		"level": "debug",
+		"tags":  map[string]any{"a": "1", "b": "2"},
+		"hosts": []any{"x", "y"},
+		"token": "s3cret",
+		"store": map[string]any{"type": "patch-redis", "addr": "r:6379", "db": 2},
+	})
+	return config, target
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	config, target := newPatchConfig(t)
+
+	if err := config.ApplyMergePatch([]byte(`{"level":null,"tags":{"a":null,"c":"3"},"port":81}`)); err != nil {
+		t.Fatalf("merge patch: %v", err)
+	}
+	if target.Level != "info" || target.Port != 81 {
+		t.Fatalf("unexpected fields: %+v", target)
+	}
+	if len(target.Tags) != 2 || target.Tags["b"] != "2" || target.Tags["c"] != "3" {
+		t.Fatalf("unexpected tags: %v", target.Tags)
+	}
+
+	if err := config.ApplyMergePatch([]byte(`{"port":null}`)); err == nil {
+		t.Fatal("expected error resetting a required field")
+	}
+}
+
+func TestApplyMergePatchPolymorphicPartial(t *testing.T) {
+	config, target := newPatchConfig(t)
+
+	if err := config.ApplyMergePatch([]byte(`{"store":{"db":5}}`)); err != nil {
+		t.Fatalf("merge patch: %v", err)
+	}
+	store, ok := target.Store.(patchRedis)
+	if !ok {
+		t.Fatalf("unexpected store type %T", target.Store)
+	}
+	if store.Addr != "r:6379" || store.DB != 5 {
+		t.Fatalf("unexpected store: %+v", store)
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	config, target := newPatchConfig(t)
+
+	patch := `[
+		{"op":"test","path":"/port","value":80},
+		{"op":"replace","path":"/port","value":8080},
+		{"op":"add","path":"/hosts/-","value":"z"},
+		{"op":"remove","path":"/hosts/0"},
+		{"op":"add","path":"/tags/a~1b","value":"slash"}
+	]`
+	if err := config.ApplyJSONPatch([]byte(patch)); err != nil {
+		t.Fatalf("json patch: %v", err)
+	}
+	if target.Port != 8080 || strings.Join(target.Hosts, ",") != "y,z" || target.Tags["a/b"] != "slash" {
+		t.Fatalf("unexpected result: %+v", target)
+	}
+
+	// A failing operation leaves the config untouched
+	if err := config.ApplyJSONPatch([]byte(`[{"op":"replace","path":"/port","value":1},{"op":"test","path":"/port","value":2}]`)); err == nil {
+		t.Fatal("expected test failure")
+	}
+	if target.Port != 8080 {
+		t.Fatalf("failed patch changed port to %d", target.Port)
+	}
+}
+
+func TestApplyJSONPatchTestMasksSecrets(t *testing.T) {
+	config, _ := newPatchConfig(t)
+
+	if err := config.ApplyJSONPatch([]byte(`[{"op":"test","path":"/token","value":"s3cret"}]`)); err == nil {
+		t.Fatal("test operation compared the unmasked secret")
+	}
+	if err := config.ApplyJSONPatch([]byte(`[{"op":"test","path":"/token","value":"******"},{"op":"replace","path":"/timeout","value":3}]`)); err != nil {
+		t.Fatalf("test against masked value: %v", err)
+	}
+}
+
+func TestApplyJSONPatchUntaggedFields(t *testing.T) {
+	type server struct {
+		MaxConns int
+	}
+	type config struct {
+		Port   int
+		Server server
+	}
+
+	c, target := newTestConfig[config](t, map[string]any{"port": 80, "server": map[string]any{"maxconns": 10}})
+	if m := c.Redacted(); m["port"] != 80 {
+		t.Fatalf("untagged field not keyed like the file: %v", m)
+	}
+	if err := c.ApplyJSONPatch([]byte(`[{"op":"replace","path":"/port","value":81},{"op":"replace","path":"/server/maxconns","value":20}]`)); err != nil {
+		t.Fatalf("json patch: %v", err)
+	}
+	if target.Port != 81 || target.Server.MaxConns != 20 {
+		t.Fatalf("unexpected result: %+v", target)
+	}
+
+	snake, _ := newTestConfig[config](t, map[string]any{"port": 80, "server": map[string]any{"max_conns": 10}}, WithMatchMode(MatchSnakeCase))
+	if server, _ := snake.Redacted()["server"].(map[string]any); server["max_conns"] != 10 {
+		t.Fatalf("untagged field not keyed in snake case: %v", snake.Redacted())
+	}
+}
@@ -0,0 +1,419 @@
+package zcfg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a location in a config source
+type Position struct {
+	File   string // Source file, empty for in-memory content
+	Line   int    // Line, starting at 1, 0 if only the file is known
+	Column int    // Column, starting at 1
+}
+
+// String returns the position as file:line:col, the file if the line is unknown,
+// or line and column for in-memory content
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	if p.File == "" {
+		return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// PositionError is an error located at the position of a key in a config source
+type PositionError struct {
+	Position Position
+	Err      error
+}
+
+// Error implements error
+func (e *PositionError) Error() string {
+	return e.Position.String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the located error
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// positionIndex maps key paths like server.port or upstreams[0].weight to their positions
+type positionIndex map[string]Position
+
+// mapKeyRegex matches non-numeric map keys in field paths, e.g. [primary] in pools[primary].size
+var mapKeyRegex = regexp.MustCompile(`\[([^\]]*[^\]0-9][^\]]*)\]`)
+
+// lookup returns the position of key path, map keys may be written as path[key] or path.key
+func (p positionIndex) lookup(path string) (Position, bool) {
+	if pos, exists := p[path]; exists {
+		return pos, true
+	}
+	pos, exists := p[mapKeyRegex.ReplaceAllString(path, ".$1")]
+	return pos, exists
+}
+
+// merge returns the positions of p for map m merged over by layer with the positions of its keys.
+// Positions of keys that layer replaces are dropped, so layers without positions like JSON files
+// don't leave the positions of the values they replaced.
+func (p positionIndex) merge(layer map[string]any, other positionIndex) positionIndex {
+	if len(p) == 0 {
+		return other
+	}
+
+	replaced := make(map[string]bool)
+	collectReplacedPaths(layer, "", replaced)
+
+	result := make(positionIndex, len(p)+len(other))
+	for path, pos := range p {
+		if !isReplacedPath(path, replaced) {
+			result[path] = pos
+		}
+	}
+	for path, pos := range other {
+		result[path] = pos
+	}
+	return result
+}
+
+// collectReplacedPaths adds the paths of the values in m that replace instead of merge with a previous layer
+func collectReplacedPaths(m map[string]any, path string, replaced map[string]bool) {
+	for key, value := range m {
+		if nested, ok := toStringMap(value); ok {
+			collectReplacedPaths(nested, joinPath(path, key), replaced)
+			continue
+		}
+		replaced[joinPath(path, key)] = true
+	}
+}
+
+// isReplacedPath checks if path or one of its parents is in replaced
+func isReplacedPath(path string, replaced map[string]bool) bool {
+	if len(replaced) == 0 {
+		return false
+	}
+	for i := len(path); i > 0; i-- {
+		if i == len(path) || path[i] == '.' || path[i] == '[' {
+			if replaced[path[:i]] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rebase returns the positions below prefix with the prefix removed
+func (p positionIndex) rebase(prefix string) positionIndex {
+	result := make(positionIndex)
+	for path, pos := range p {
+		if rest, ok := strings.CutPrefix(path, prefix+"."); ok {
+			result[rest] = pos
+		}
+	}
+	return result
+}
+
+// keyPath returns the key path the field at fieldPath was read from, resolving matched key names
+func (ctx *mapContext) keyPath(fieldPath string) string {
+	if key, exists := ctx.keys[fieldPath]; exists {
+		return key
+	}
+	if i := strings.LastIndex(fieldPath, "["); i > 0 && strings.HasSuffix(fieldPath, "]") {
+		return ctx.keyPath(fieldPath[:i]) + fieldPath[i:]
+	}
+	return fieldPath
+}
+
+// recordKey records the key the field at fieldPath was read from
+func (ctx *mapContext) recordKey(basePath, fieldPath, key string) {
+	if ctx.positions == nil {
+		return
+	}
+	if ctx.keys == nil {
+		ctx.keys = make(map[string]string)
+	}
+	ctx.keys[fieldPath] = joinPath(ctx.keyPath(basePath), key)
+}
+
+// position returns the source position of the field at fieldPath
+func (ctx *mapContext) position(fieldPath string) (Position, bool) {
+	if ctx == nil || ctx.positions == nil || fieldPath == "" {
+		return Position{}, false
+	}
+	return ctx.positions.lookup(ctx.keyPath(fieldPath))
+}
+
+// keyPosition returns the source position of key in the map of the field at basePath, nil if unknown
+func (ctx *mapContext) keyPosition(basePath, key string) *Position {
+	if ctx.positions == nil {
+		return nil
+	}
+	if pos, exists := ctx.positions.lookup(joinPath(ctx.keyPath(basePath), key)); exists {
+		return &pos
+	}
+	return nil
+}
+
+// locate wraps err with the source position of the field at fieldPath, unless it is already located
+func (ctx *mapContext) locate(err error, fieldPath string) error {
+	var positionErr *PositionError
+	if err == nil || errors.As(err, &positionErr) {
+		return err
+	}
+	if pos, exists := ctx.position(fieldPath); exists {
+		return &PositionError{Position: pos, Err: err}
+	}
+	return err
+}
+
+// parseYAMLPositions returns the positions of the keys and list items in a YAML document
+func parseYAMLPositions(node *yaml.Node, file string) positionIndex {
+	positions := make(positionIndex)
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		collectYAMLPositions(node.Content[0], "", file, positions, 0)
+	}
+	return positions
+}
+
+// collectYAMLPositions records the positions below node at path
+func collectYAMLPositions(node *yaml.Node, path, file string, positions positionIndex, depth int) {
+	// Bound alias expansion, anchors may refer to themselves
+	if depth > 100 {
+		return
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		collectYAMLPositions(node.Alias, path, file, positions, depth+1)
+	case yaml.MappingNode:
+		// Merge keys first so explicit keys take precedence
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != "<<" {
+				continue
+			}
+			sources := []*yaml.Node{node.Content[i+1]}
+			if sources[0].Kind == yaml.SequenceNode {
+				sources = sources[0].Content
+			}
+			for _, source := range sources {
+				collectYAMLPositions(source, path, file, positions, depth+1)
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			keyPath := joinPath(path, key.Value)
+			positions[keyPath] = Position{File: file, Line: key.Line, Column: key.Column}
+			collectYAMLPositions(value, keyPath, file, positions, depth+1)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			positions[itemPath] = Position{File: file, Line: item.Line, Column: item.Column}
+			collectYAMLPositions(item, itemPath, file, positions, depth+1)
+		}
+	}
+}
+
+// filePositions returns positions naming only file for all keys in m, for formats without line information
+func filePositions(m map[string]any, file string) positionIndex {
+	positions := make(positionIndex)
+	collectFilePositions(m, "", file, positions)
+	return positions
+}
+
+// collectFilePositions adds file positions for value at path and the keys and items below it
+func collectFilePositions(value any, path, file string, positions positionIndex) {
+	if path != "" {
+		positions[path] = Position{File: file}
+	}
+	if m, ok := toStringMap(value); ok {
+		for key, item := range m {
+			collectFilePositions(item, joinPath(path, key), file, positions)
+		}
+		return
+	}
+	if items, ok := value.([]any); ok {
+		for i, item := range items {
+			collectFilePositions(item, fmt.Sprintf("%s[%d]", path, i), file, positions)
+		}
+	}
+}
+
+// parseTOMLPositions returns the positions of the keys and tables in a TOML document.
+// Keys inside inline tables and arrays are not indexed.
+func parseTOMLPositions(data []byte, file string) positionIndex {
+	positions := make(positionIndex)
+	arrays := make(map[string]int) // Number of tables in each array of tables
+	var table string
+	var scanner tomlScanner
+
+	for i, line := range strings.Split(string(data), "\n") {
+		// Skip continuation lines of multi-line strings and arrays
+		if scanner.open() {
+			scanner.scan(line)
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		pos := Position{File: file, Line: i + 1, Column: len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			end := strings.Index(trimmed, "]]")
+			if end < 0 {
+				continue
+			}
+			table = resolveTOMLTable(splitTOMLKey(trimmed[2:end]), arrays, true)
+			positions[table] = pos
+		case trimmed[0] == '[':
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = resolveTOMLTable(splitTOMLKey(trimmed[1:end]), arrays, false)
+			positions[table] = pos
+		default:
+			eq := tomlKeyEnd(trimmed)
+			if eq < 0 {
+				continue
+			}
+			path := table
+			for _, part := range splitTOMLKey(trimmed[:eq]) {
+				path = joinPath(path, part)
+				if _, exists := positions[path]; !exists {
+					positions[path] = pos
+				}
+			}
+			positions[path] = pos
+			scanner.scan(trimmed[eq+1:])
+		}
+	}
+	return positions
+}
+
+// resolveTOMLTable returns the path of a table header, indexing arrays of tables.
+// With array set the header opens a new table in the array of tables.
+func resolveTOMLTable(parts []string, arrays map[string]int, array bool) string {
+	var path string
+	for i, part := range parts {
+		path = joinPath(path, part)
+		n, isArray := arrays[path]
+		if array && i == len(parts)-1 {
+			arrays[path] = n + 1
+			return fmt.Sprintf("%s[%d]", path, n)
+		}
+		if isArray && n > 0 {
+			path = fmt.Sprintf("%s[%d]", path, n-1)
+		}
+	}
+	return path
+}
+
+// tomlKeyEnd returns the index of the = separating key and value, or -1
+func tomlKeyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		case c == '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// splitTOMLKey splits a dotted TOML key into its unquoted parts
+func splitTOMLKey(key string) []string {
+	var parts []string
+	var current strings.Builder
+	var quote byte
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		case c != ' ' && c != '\t':
+			current.WriteByte(c)
+		}
+	}
+	return append(parts, strings.TrimSpace(current.String()))
+}
+
+// tomlScanner tracks multi-line strings and arrays spanning lines
+type tomlScanner struct {
+	delim string // Closing delimiter of an open multi-line string
+	depth int    // Open brackets and braces
+}
+
+// open reports whether a value continues on the next line
+func (s *tomlScanner) open() bool {
+	return s.delim != "" || s.depth > 0
+}
+
+// scan consumes a line of a value
+func (s *tomlScanner) scan(line string) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if s.delim != "" {
+			switch {
+			case strings.HasPrefix(line[i:], s.delim):
+				i += len(s.delim) - 1
+				s.delim = ""
+			case c == '\\' && s.delim == `"""`:
+				i++
+			}
+			continue
+		}
+
+		switch c {
+		case '#':
+			return
+		case '[', '{':
+			s.depth++
+		case ']', '}':
+			s.depth = max(s.depth-1, 0)
+		case '"', '\'':
+			delim := strings.Repeat(string(c), 3)
+			if strings.HasPrefix(line[i:], delim) {
+				s.delim = delim
+				i += 2
+				continue
+			}
+			// Skip a single-line string
+			for i++; i < len(line) && line[i] != c; i++ {
+				if c == '"' && line[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+}
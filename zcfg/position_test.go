@@ -0,0 +1,64 @@
+package zcfg
+
+import (
+	"errors"
+	"testing"
+)
+
+type positionConfig struct {
+	Server struct {
+		Host string `meta:"host"`
+		Port int    `meta:"port"`
+	} `meta:"server"`
+}
+
+func TestPositionsOfOverriddenKeys(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", "server:\n  host: localhost\n  port: 80\n")
+	yamlOverlay := writeFile(t, dir, "local.yaml", "server:\n  port: http\n")
+	jsonOverlay := writeFile(t, dir, "local.json", `{"server": {"port": "http"}}`)
+
+	_, err := Load[positionConfig](base, WithUseEnv(false), WithRegistry(NewRegistry()), WithOverlay(yamlOverlay))
+	var positionErr *PositionError
+	if !errors.As(err, &positionErr) {
+		t.Fatalf("expected position error, got %v", err)
+	}
+	if positionErr.Position != (Position{File: yamlOverlay, Line: 2, Column: 3}) {
+		t.Fatalf("unexpected position %v", positionErr.Position)
+	}
+
+	_, err = Load[positionConfig](base, WithUseEnv(false), WithRegistry(NewRegistry()), WithOverlay(jsonOverlay))
+	if !errors.As(err, &positionErr) {
+		t.Fatalf("expected position error, got %v", err)
+	}
+	if positionErr.Position != (Position{File: jsonOverlay}) {
+		t.Fatalf("error located in the replaced base value: %v", err)
+	}
+}
+
+func TestPositionMergeDropsReplacedKeys(t *testing.T) {
+	base := positionIndex{
+		"server":         {Line: 1},
+		"server.host":    {Line: 2},
+		"server.port":    {Line: 3},
+		"upstreams":      {Line: 4},
+		"upstreams[0]":   {Line: 5},
+		"upstreams[0].w": {Line: 6},
+	}
+	layer := map[string]any{
+		"server":    map[string]any{"port": 8080},
+		"upstreams": []any{},
+	}
+
+	merged := base.merge(layer, nil)
+	for _, path := range []string{"server.port", "upstreams", "upstreams[0]", "upstreams[0].w"} {
+		if _, exists := merged[path]; exists {
+			t.Fatalf("position of replaced key %s kept", path)
+		}
+	}
+	for _, path := range []string{"server", "server.host"} {
+		if _, exists := merged[path]; !exists {
+			t.Fatalf("position of merged key %s dropped", path)
+		}
+	}
+}
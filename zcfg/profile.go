@@ -0,0 +1,145 @@
+package zcfg
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// resolveProfile returns the active profile from the option or the profile environment variable
+func resolveProfile(option *Option, env *envLookup) string {
+	if option.Profile != "" {
+		return option.Profile
+	}
+	if option.ProfileEnv != "" && option.UseEnv {
+		return strings.TrimSpace(env.lookup(option.ProfileEnv))
+	}
+	return ""
+}
+
+// applyProfile returns m with the profiles section removed and the active profile merged over it.
+// The matching block of the profiles section is merged first, then the profile-specific sibling
+// file, e.g. config.prod.yaml next to config.yaml.
+func (c *Config) applyProfile(m map[string]any, positions positionIndex) (map[string]any, positionIndex, error) {
+	sectionKey := profileSectionKey(c.target, c.option)
+	profileKey, _ := findKeyInMap(m, sectionKey, c.option)
+	profiles, m := extractProfiles(m, sectionKey, c.option)
+	if c.profile == "" {
+		return m, positions, nil
+	}
+	if strings.ContainsAny(c.profile, `/\`) || strings.Contains(c.profile, "..") {
+		return nil, nil, fmt.Errorf("invalid profile name '%s'", c.profile)
+	}
+
+	found := false
+	if key, exists := findKeyInMap(profiles, c.profile, c.option); exists {
+		block, ok := toStringMap(profiles[key])
+		if !ok {
+			return nil, nil, fmt.Errorf("profile %s expected map, got %T", c.profile, profiles[key])
+		}
+		m = MergeMaps(m, block)
+		positions = positions.merge(block, positions.rebase(profileKey+"."+key))
+		found = true
+	}
+
+	if c.file != "" {
+		profileMap, profilePositions, err := readConfigFile(c.fsys, profileFileName(c.file, c.profile))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, nil, err
+		default:
+			_, profileMap = extractProfiles(profileMap, sectionKey, c.option)
+			m = MergeMaps(m, profileMap)
+			positions = positions.merge(profileMap, profilePositions)
+			found = true
+		}
+	}
+
+	// Only warn for explicitly selected profiles, the environment variable may be shared by many configs
+	if !found && c.option.Profile != "" {
+		c.option.logger().Warn().Str("profile", c.profile).Msg("no config found for profile")
+	}
+	return m, positions, nil
+}
+
+// profileSectionKey returns the key of the in-file profiles section, empty if there is none.
+// A config struct with a field for the key keeps it as a regular value.
+func profileSectionKey(target any, option *Option) string {
+	if option.ProfileKey == "" || hasFieldForKey(reflect.TypeOf(target), option.ProfileKey, option) {
+		return ""
+	}
+	return option.ProfileKey
+}
+
+// hasFieldForKey checks if struct type t has a field mapped from key, including deprecated aliases
+func hasFieldForKey(t reflect.Type, key string, option *Option) bool {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	keyMap := map[string]any{key: nil}
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+		if fieldType.Anonymous && isStructType(fieldType.Type) {
+			if hasFieldForKey(fieldType.Type, key, option) {
+				return true
+			}
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		for _, name := range append([]string{fieldName}, tagInfo.Aliases...) {
+			if _, exists := findKeyInMap(keyMap, name, option); exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// extractProfiles splits the profiles section at sectionKey from m, m is not modified
+func extractProfiles(m map[string]any, sectionKey string, option *Option) (map[string]any, map[string]any) {
+	if sectionKey == "" {
+		return nil, m
+	}
+	key, exists := findKeyInMap(m, sectionKey, option)
+	if !exists {
+		return nil, m
+	}
+
+	profiles, _ := toStringMap(m[key])
+	rest := make(map[string]any, len(m)-1)
+	for k, v := range m {
+		if k != key {
+			rest[k] = v
+		}
+	}
+	return profiles, rest
+}
+
+// profileFileName returns the profile-specific sibling of file, e.g. config.prod.yaml for config.yaml
+func profileFileName(file, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "." + profile + ext
+}
+
+// Profile returns the active profile, empty if none
+func (c *Config) Profile() string {
+	return c.profile
+}
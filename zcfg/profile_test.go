@@ -0,0 +1,32 @@
+package zcfg
+
+import "testing"
+
+func TestProfileKeyKeptForStructField(t *testing.T) {
+	type config struct {
+		Profiles map[string]string `meta:"profiles"`
+	}
+
+	cfg, err := LoadFromBytes[config]([]byte(`{"profiles":{"a":"b"}}`), FormatJSON, WithUseEnv(false), WithRegistry(NewRegistry()))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Profiles["a"] != "b" {
+		t.Fatalf("unexpected profiles: %v", cfg.Profiles)
+	}
+}
+
+func TestProfileSectionApplied(t *testing.T) {
+	type config struct {
+		Port int `meta:"port"`
+	}
+
+	data := []byte(`{"port":80,"profiles":{"prod":{"port":443}}}`)
+	cfg, err := LoadFromBytes[config](data, FormatJSON, WithUseEnv(false), WithProfile("prod"), WithStrict(true), WithRegistry(NewRegistry()))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Port != 443 {
+		t.Fatalf("expected profile port, got %d", cfg.Port)
+	}
+}
@@ -0,0 +1,203 @@
+package zcfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SourceKind describes where a resolved field value came from
+type SourceKind int
+
+const (
+	SourceFile     SourceKind = iota // Value read from a config file
+	SourceData                       // Value read from in-memory content, e.g. LoadFromJson
+	SourceEnv                        // Value expanded from environment variables
+	SourceDefault                    // Value taken from the default= tag
+	SourceUpdate                     // Value set at runtime by Update
+	SourceRollback                   // Value restored from history by RollbackTo
+	SourceRemote                     // Value fetched from a remote source
+)
+
+// String returns the source kind name
+func (k SourceKind) String() string {
+	switch k {
+	case SourceFile:
+		return "file"
+	case SourceData:
+		return "data"
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	case SourceUpdate:
+		return "update"
+	case SourceRollback:
+		return "rollback"
+	case SourceRemote:
+		return "remote"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (k SourceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Provenance records the origin of a resolved field value
+type Provenance struct {
+	Path    string     // Field path, e.g. server.port
+	Kind    SourceKind // Kind of source
+	File    string     // Source file, empty if not loaded from a file
+	Line    int        // Line in source file, 0 if unknown
+	Column  int        // Column in source file, 0 if unknown
+	EnvVars []string   // Environment variables referenced by the value
+	Time    time.Time  // Time the value was applied
+	Value   any        // Resolved value before conversion to the field type
+}
+
+// String returns a human-readable description of the origin
+func (p Provenance) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s = %v from %s", p.Path, p.Value, p.Kind)
+
+	if len(p.EnvVars) > 0 {
+		fmt.Fprintf(&b, " ${%s}", strings.Join(p.EnvVars, "}, ${"))
+	}
+	if p.File != "" {
+		b.WriteString(" in " + p.File)
+		if p.Line > 0 {
+			fmt.Fprintf(&b, ":%d", p.Line)
+			if p.Column > 0 {
+				fmt.Fprintf(&b, ":%d", p.Column)
+			}
+		}
+	}
+	if !p.Time.IsZero() {
+		b.WriteString(" at " + p.Time.Format(time.RFC3339))
+	}
+
+	return b.String()
+}
+
+// envVarNames returns the environment variables referenced by value
+func envVarNames(value any) []string {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, match := range envVarRegex.FindAllStringSubmatch(s, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// recordOrigin records the provenance of a field resolved from the map or a default
+func (ctx *mapContext) recordOrigin(fieldPath string, rawValue, value any, isDefault bool) {
+	p := ctx.source
+	p.Path = fieldPath
+	p.Value = value
+	if ctx.isSecret(fieldPath) {
+		p.Value = RedactedValue
+	}
+	if isDefault {
+		p.Kind = SourceDefault
+		p.File = ""
+	} else if pos, exists := ctx.position(fieldPath); exists {
+		p.File, p.Line, p.Column = pos.File, pos.Line, pos.Column
+	}
+	if names := envVarNames(rawValue); len(names) > 0 {
+		p.Kind = SourceEnv
+		p.EnvVars = names
+	}
+
+	ctx.provenance[fieldPath] = p
+}
+
+// recordReplaced records a container field whose nested values were replaced as a whole
+func (ctx *mapContext) recordReplaced(fieldPath string) {
+	ctx.replaced = append(ctx.replaced, fieldPath)
+}
+
+// markPresent records that the field at path was explicitly provided
+func (ctx *mapContext) markPresent(fieldPath string) {
+	if ctx.present == nil {
+		ctx.present = make(map[string]bool)
+	}
+	ctx.present[fieldPath] = true
+}
+
+// mergeProvenance merges provenance recorded in ctx into existing records
+func mergeProvenance(existing map[string]Provenance, ctx *mapContext) map[string]Provenance {
+	return mergeRecords(existing, ctx.provenance, ctx.replaced)
+}
+
+// mergePresence merges fields provided in ctx into the existing set
+func mergePresence(existing map[string]bool, ctx *mapContext) map[string]bool {
+	return mergeRecords(existing, ctx.present, ctx.replaced)
+}
+
+// mergeRecords merges per-path records, dropping existing records of replaced fields and below
+func mergeRecords[V any](existing, records map[string]V, replaced []string) map[string]V {
+	result := make(map[string]V, len(existing)+len(records))
+	for path, r := range existing {
+		result[path] = r
+	}
+
+	// Drop records of replaced fields and below, their values were rebuilt
+	for _, prefix := range replaced {
+		for path := range result {
+			if isSubPath(path, prefix) {
+				delete(result, path)
+			}
+		}
+	}
+
+	for path, r := range records {
+		result[path] = r
+	}
+
+	return result
+}
+
+// isSubPath checks if path is prefix or a path below it
+func isSubPath(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[")
+}
+
+// Explain returns the origin of the field at path, e.g. "server.port" or "upstreams[0].weight"
+func (c *Config) Explain(path string) (Provenance, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	p, exists := c.origins[path]
+	return p, exists
+}
+
+// IsSet reports whether the field at path, e.g. "tls" or "server.port", was explicitly
+// provided by the config source or an update rather than defaulted or left empty
+func (c *Config) IsSet(path string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.present[path]
+}
+
+// Provenance returns the origin of every resolved field, sorted by path
+func (c *Config) Provenance() []Provenance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]Provenance, 0, len(c.origins))
+	for _, p := range c.origins {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
@@ -0,0 +1,131 @@
+package zcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type omitNilTLS struct {
+	Cert string `meta:"cert,default=server.pem"`
+}
+
+type omitNilConfig struct {
+	Port    int         `meta:"port,default=80"`
+	TLS     *omitNilTLS `meta:"tls,optional,omitnil"`
+	Timeout *int        `meta:"timeout,optional,omitnil"`
+}
+
+type requiredOmitNilConfig struct {
+	Timeout *int `meta:"timeout,omitnil"`
+}
+
+func TestOmitNil(t *testing.T) {
+	_, target := newTestConfig[omitNilConfig](t, map[string]any{})
+	if target.TLS != nil || target.Timeout != nil {
+		t.Fatalf("missing omitnil pointers were allocated: %+v", target)
+	}
+
+	_, target = newTestConfig[omitNilConfig](t, map[string]any{"tls": map[string]any{}, "timeout": 5})
+	if target.TLS == nil || target.TLS.Cert != "server.pem" || target.Timeout == nil || *target.Timeout != 5 {
+		t.Fatalf("unexpected values: %+v", target)
+	}
+
+	// omitnil does not make a pointer optional
+	if _, err := loadTestConfig[requiredOmitNilConfig](map[string]any{}); err == nil || !strings.Contains(err.Error(), "timeout is required") {
+		t.Fatalf("expected required error, got %v", err)
+	}
+	if _, err := loadTestConfig[requiredOmitNilConfig](map[string]any{}, WithOmitNil(true)); err == nil {
+		t.Fatal("expected required error with WithOmitNil")
+	}
+}
+
+func TestOmitNilMergePatch(t *testing.T) {
+	config, target := newTestConfig[omitNilConfig](t, map[string]any{"tls": map[string]any{"cert": "a.pem"}})
+	if err := config.ApplyMergePatch([]byte(`{"tls": null}`)); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if target.TLS != nil {
+		t.Fatalf("omitnil pointer not reset to nil: %+v", target.TLS)
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	config, _ := newTestConfig[omitNilConfig](t, map[string]any{"tls": map[string]any{}})
+	for path, want := range map[string]bool{"tls": true, "tls.cert": false, "port": false, "timeout": false} {
+		if got := config.IsSet(path); got != want {
+			t.Errorf("IsSet(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	if err := config.Update(map[string]any{"port": 8080, "timeout": 3}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !config.IsSet("port") || !config.IsSet("timeout") || !config.IsSet("tls") {
+		t.Fatal("fields set by the load and the update are not reported")
+	}
+
+	// A merge patch null resets the field to unset
+	if err := config.ApplyMergePatch([]byte(`{"port": null}`)); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if config.IsSet("port") {
+		t.Fatal("reset field is still reported as set")
+	}
+}
+
+type provenanceConfig struct {
+	Host     string `meta:"host"`
+	Port     int    `meta:"port,default=80"`
+	User     string `meta:"user"`
+	Password string `meta:"password,secret"`
+}
+
+func TestExplain(t *testing.T) {
+	t.Setenv("ZCFG_TEST_USER", "alice")
+	file := writeFile(t, t.TempDir(), "app.yaml", "host: example.com\nuser: ${ZCFG_TEST_USER}\npassword: hunter2\n")
+
+	registry := NewRegistry()
+	if _, err := Load[provenanceConfig](file, WithRegistry(registry), WithUpdatable(true), WithEnvFiles()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	config := GetFrom[provenanceConfig](registry, "")
+	if config == nil {
+		t.Fatal("config not registered")
+	}
+
+	host, exists := config.Explain("host")
+	if !exists || host.Kind != SourceFile || host.File != file || host.Line != 1 || host.Value != "example.com" {
+		t.Fatalf("unexpected host provenance: %+v", host)
+	}
+	if user, _ := config.Explain("user"); user.Kind != SourceEnv || user.Line != 2 || len(user.EnvVars) != 1 || user.EnvVars[0] != "ZCFG_TEST_USER" {
+		t.Fatalf("unexpected user provenance: %+v", user)
+	}
+	if port, _ := config.Explain("port"); port.Kind != SourceDefault || port.File != "" || port.Value != "80" {
+		t.Fatalf("unexpected port provenance: %+v", port)
+	}
+	if password, _ := config.Explain("password"); password.Value != RedactedValue || strings.Contains(password.String(), "hunter2") {
+		t.Fatalf("secret provenance not masked: %+v", password)
+	}
+	if _, exists := config.Explain("missing"); exists {
+		t.Fatal("unexpected provenance for unknown path")
+	}
+
+	if err := config.Update(map[string]any{"port": 8080}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	port, _ := config.Explain("port")
+	if port.Kind != SourceUpdate || port.Value != 8080 || port.Time.IsZero() {
+		t.Fatalf("unexpected updated port provenance: %+v", port)
+	}
+	if host, _ := config.Explain("host"); host.Kind != SourceFile {
+		t.Fatalf("update changed the provenance of other fields: %+v", host)
+	}
+
+	var paths []string
+	for _, p := range config.Provenance() {
+		paths = append(paths, p.Path)
+	}
+	if strings.Join(paths, ",") != "host,password,port,user" {
+		t.Fatalf("unexpected provenance paths: %v", paths)
+	}
+}
@@ -0,0 +1,477 @@
+package zcfg
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/meta-apex/zenith/zlog"
+)
+
+// RedactedValue replaces secret values in redacted output
+const RedactedValue = "******"
+
+// secretKeyPatterns are key name fragments that mark a value as sensitive
+var secretKeyPatterns = []string{
+	"password", "passwd", "secret", "token", "apikey", "api_key", "privatekey", "private_key", "credential",
+}
+
+// isSecretKey checks if a key name looks like it holds a sensitive value
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, pattern := range secretKeyPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactMap returns a copy of m with values of sensitive-looking keys masked.
+// Values at secretKeys, e.g. from SecretKeys, are masked as well.
+func RedactMap(m map[string]any, secretKeys ...string) map[string]any {
+	masked, _ := maskSecretKeys(m, "", newKeySet(secretKeys)).(map[string]any)
+	result := make(map[string]any, len(masked))
+	for key, value := range masked {
+		if isSecretKey(key) && value != nil {
+			result[key] = RedactedValue
+			continue
+		}
+		result[key] = redactAny(value)
+	}
+	return result
+}
+
+// newKeySet returns keys as a set
+func newKeySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// isSecretKeyPath checks if path is one of the secret key paths or below one
+func isSecretKeyPath(path string, secretKeys map[string]bool) bool {
+	for key := range secretKeys {
+		if isSubPath(path, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskSecretKeys returns a copy of the raw value at path with the values at secret key paths masked
+func maskSecretKeys(value any, path string, secretKeys map[string]bool) any {
+	if value == nil || len(secretKeys) == 0 {
+		return value
+	}
+	if secretKeys[path] {
+		return RedactedValue
+	}
+	if m, ok := toStringMap(value); ok {
+		result := make(map[string]any, len(m))
+		for key, item := range m {
+			result[key] = maskSecretKeys(item, joinPath(path, key), secretKeys)
+		}
+		return result
+	}
+	if s, ok := value.([]any); ok {
+		result := make([]any, len(s))
+		for i, item := range s {
+			result[i] = maskSecretKeys(item, fmt.Sprintf("%s[%d]", path, i), secretKeys)
+		}
+		return result
+	}
+	return value
+}
+
+// SecretKeys returns the key paths in the raw map m that hold secret fields of T, e.g. db.password
+// or users[0].token. Fields are secret if tagged with the secret option or of type Secret.
+// Pass them to RedactMap or RedactChanges to mask values that key names alone do not reveal.
+func SecretKeys[T any](m map[string]any, opts ...func(*Option)) []string {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	var keys []string
+	collectSecretKeys(reflect.TypeOf((*T)(nil)).Elem(), m, "", option, false, &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// secretKeySet returns the key paths in m that hold secret fields of type t
+func secretKeySet(t reflect.Type, m map[string]any, option *Option) map[string]bool {
+	var keys []string
+	collectSecretKeys(t, m, "", option, false, &keys)
+	return newKeySet(keys)
+}
+
+// collectSecretKeys appends the key paths of the secret fields of type t in the raw value at path
+func collectSecretKeys(t reflect.Type, value any, path string, option *Option, secret bool, keys *[]string) {
+	if value == nil {
+		return
+	}
+	t = indirectType(t)
+	if secret || t == secretType {
+		*keys = append(*keys, path)
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := toStringMap(value)
+		if !ok || isUnitType(t) {
+			return
+		}
+		collectSecretFields(t, m, path, option, keys)
+	case reflect.Interface:
+		m, ok := toStringMap(value)
+		if !ok {
+			return
+		}
+		name, _ := findValueInMap(m, option.typeKey(), option)
+		if nameStr, ok := name.(string); ok {
+			if concreteType, exists := lookupType(t, nameStr); exists {
+				collectSecretKeys(concreteType, m, path, option, false, keys)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, _ := toSlice(value)
+		for i, item := range items {
+			collectSecretKeys(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), option, false, keys)
+		}
+	case reflect.Map:
+		m, _ := toStringMap(value)
+		for key, item := range m {
+			collectSecretKeys(t.Elem(), item, joinPath(path, key), option, false, keys)
+		}
+	}
+}
+
+// collectSecretFields appends the key paths of the secret fields of struct type t in m.
+// Keys of deprecated aliases are included.
+func collectSecretFields(t reflect.Type, m map[string]any, path string, option *Option, keys *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+		if fieldType.Anonymous && isStructType(fieldType.Type) {
+			collectSecretFields(indirectType(fieldType.Type), m, path, option, keys)
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		secret := tagInfo.Secret || isSecretType(fieldType.Type)
+		for _, name := range append([]string{fieldName}, tagInfo.Aliases...) {
+			if key, exists := findKeyInMap(m, name, option); exists {
+				collectSecretKeys(fieldType.Type, m[key], joinPath(path, key), option, secret, keys)
+			}
+		}
+	}
+}
+
+// redactAny redacts nested maps and slices in a raw value
+func redactAny(value any) any {
+	if m, ok := toStringMap(value); ok {
+		return RedactMap(m)
+	}
+	if s, ok := value.([]any); ok {
+		result := make([]any, len(s))
+		for i, item := range s {
+			result[i] = redactAny(item)
+		}
+		return result
+	}
+	return value
+}
+
+// RedactChanges returns a copy of changes with values of sensitive-looking keys masked.
+// Values at secretKeys, e.g. from SecretKeys, are masked as well.
+func RedactChanges(changes []Change, secretKeys ...string) []Change {
+	keySet := newKeySet(secretKeys)
+	result := make([]Change, len(changes))
+	for i, change := range changes {
+		if isSecretPath(change.Path) || isSecretKeyPath(change.Path, keySet) {
+			if change.Old != nil {
+				change.Old = RedactedValue
+			}
+			if change.New != nil {
+				change.New = RedactedValue
+			}
+		} else {
+			change.Old = redactAny(maskSecretKeys(change.Old, change.Path, keySet))
+			change.New = redactAny(maskSecretKeys(change.New, change.Path, keySet))
+		}
+		result[i] = change
+	}
+	return result
+}
+
+// isSecretPath checks if any key in a path like db.password or users[0].token is sensitive
+func isSecretPath(path string) bool {
+	for _, part := range strings.Split(path, ".") {
+		if idx := strings.Index(part, "["); idx >= 0 {
+			part = part[:idx]
+		}
+		if isSecretKey(part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret is a string that is masked when printed, logged or encoded.
+// Use Value to access the real value.
+type Secret string
+
+// Value returns the unmasked secret
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String returns the masked value, or an empty string if the secret is unset
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// GoString implements fmt.GoStringer so %#v is masked as well
+func (s Secret) GoString() string {
+	return fmt.Sprintf("zcfg.Secret(%q)", s.String())
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// isSecretType checks if type is Secret or a pointer to it
+func isSecretType(t reflect.Type) bool {
+	return indirectType(t) == secretType
+}
+
+// markSecret records that the field at path and everything below it is secret
+func (ctx *mapContext) markSecret(path string) {
+	if ctx.secrets == nil {
+		ctx.secrets = make(map[string]bool)
+	}
+	ctx.secrets[path] = true
+}
+
+// isSecret checks if the field at path or one of its parents is secret
+func (ctx *mapContext) isSecret(path string) bool {
+	for path != "" {
+		if ctx.secrets[path] {
+			return true
+		}
+		idx := strings.LastIndexAny(path, ".[")
+		if idx < 0 {
+			return false
+		}
+		path = path[:idx]
+	}
+	return false
+}
+
+// Redact returns a map view of struct v with secret fields masked.
+// Fields are secret if tagged with the secret option or of type Secret.
+func Redact(v any, opts ...func(*Option)) map[string]any {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	m, _ := redactValue(reflect.ValueOf(v), option, false, maskRedacted).(map[string]any)
+	return m
+}
+
+// maskRedacted replaces a secret value with RedactedValue
+func maskRedacted(reflect.Value) any {
+	return RedactedValue
+}
+
+// secretDigest stands in for a secret value in diffs. Digests of equal values compare
+// equal so changed secrets are detected, but only RedactedValue is ever printed.
+type secretDigest struct {
+	sum [sha256.Size]byte
+}
+
+// String implements fmt.Stringer
+func (secretDigest) String() string {
+	return RedactedValue
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (secretDigest) MarshalText() ([]byte, error) {
+	return []byte(RedactedValue), nil
+}
+
+// maskDigest replaces a secret value with its secretDigest
+func maskDigest(v reflect.Value) any {
+	data, _ := json.Marshal(v.Interface())
+	if s, ok := v.Interface().(Secret); ok {
+		data = []byte(s)
+	}
+	var digest secretDigest
+	copy(digest.sum[:], keyedHash(data))
+	return digest
+}
+
+// redactValue converts value to a raw value with secret fields replaced by mask.
+// A nil mask keeps secret values as they are.
+func redactValue(v reflect.Value, option *Option, secret bool, mask func(reflect.Value) any) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if mask != nil && (secret || v.Type() == secretType) && !v.IsZero() {
+		return mask(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		result := redactValue(v.Elem(), option, secret, mask)
+
+		// Add the discriminator so polymorphic values can be decoded again
+		if m, ok := result.(map[string]any); ok && v.Kind() == reflect.Interface {
+			if name, ok := registeredTypeName(v.Type(), indirectType(v.Elem().Type())); ok {
+				m[option.typeKey()] = name
+			}
+		}
+		return result
+
+	case reflect.Struct:
+		if isUnitType(v.Type()) {
+			return fmt.Sprintf("%v", v.Interface())
+		}
+		result := make(map[string]any)
+		redactFields(v, option, result, mask)
+		return result
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		result := make([]any, v.Len())
+		for i := range result {
+			result[i] = redactValue(v.Index(i), option, false, mask)
+		}
+		return result
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		result := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[fmt.Sprintf("%v", iter.Key().Interface())] = redactValue(iter.Value(), option, false, mask)
+		}
+		return result
+	}
+
+	if !v.CanInterface() {
+		return nil
+	}
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case Secret:
+		return value.Value()
+	}
+	return v.Interface()
+}
+
+// redactFields adds the exported fields of struct v to result, embedded structs are flattened
+func redactFields(v reflect.Value, option *Option, result map[string]any, mask func(reflect.Value) any) {
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+
+		if fieldType.Anonymous && indirectType(field.Type()).Kind() == reflect.Struct {
+			for field.Kind() == reflect.Ptr && !field.IsNil() {
+				field = field.Elem()
+			}
+			if field.Kind() == reflect.Struct {
+				redactFields(field, option, result, mask)
+			}
+			continue
+		}
+
+		result[fieldKey(fieldType, tagInfo, option)] = redactValue(field, option, tagInfo.Secret, mask)
+	}
+}
+
+// Redacted returns the effective configuration as a map with secret fields masked
+func (c *Config) Redacted() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	m, _ := redactValue(reflect.ValueOf(c.target), c.option, false, maskRedacted).(map[string]any)
+	return m
+}
+
+// digestView returns the effective configuration as a map with secret fields replaced
+// by digests, suitable for diffing without exposing secrets. Caller must hold c.mu.
+func (c *Config) digestView() map[string]any {
+	m, _ := redactValue(reflect.ValueOf(c.target), c.option, false, maskDigest).(map[string]any)
+	return m
+}
+
+// String returns the effective configuration as JSON with secret fields masked
+func (c *Config) String() string {
+	data, err := json.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("zcfg.Config(%v)", err)
+	}
+	return string(data)
+}
+
+// MarshalObject implements zlog.ObjectMarshaler, logging the config with secret fields masked
+func (c *Config) MarshalObject(e *zlog.Entry) {
+	m := c.Redacted()
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		e.Interface(key, m[key])
+	}
+}
@@ -0,0 +1,50 @@
+package zcfg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type redactConfig struct {
+	DB struct {
+		Pass string `meta:"pass,secret"`
+		Host string `meta:"host"`
+	} `meta:"db"`
+	Auth Secret `meta:"auth"`
+}
+
+func TestGetMapMasksSecretFields(t *testing.T) {
+	config, _ := newTestConfig[redactConfig](t, map[string]any{"db": map[string]any{"pass": "hunter2", "host": "localhost"}, "auth": "tok"})
+
+	m := config.GetMap()
+	db := m["db"].(map[string]any)
+	if db["pass"] != RedactedValue || m["auth"] != RedactedValue || db["host"] != "localhost" {
+		t.Fatalf("unexpected map: %v", m)
+	}
+	if value, _ := config.GetValue("db.pass"); value != RedactedValue {
+		t.Fatalf("GetValue leaked secret: %v", value)
+	}
+	if value, _ := config.GetValue("db"); strings.Contains(fmt.Sprint(value), "hunter2") {
+		t.Fatalf("GetValue leaked nested secret: %v", value)
+	}
+}
+
+func TestRedactWithSecretKeys(t *testing.T) {
+	old := map[string]any{"db": map[string]any{"pass": "a"}, "auth": "x"}
+	updated := map[string]any{"db": map[string]any{"pass": "b"}, "auth": "y"}
+
+	keys := SecretKeys[redactConfig](updated)
+	if strings.Join(keys, ",") != "auth,db.pass" {
+		t.Fatalf("unexpected secret keys: %v", keys)
+	}
+
+	for _, change := range RedactChanges(Diff(old, updated), keys...) {
+		if change.Old != RedactedValue || change.New != RedactedValue {
+			t.Fatalf("change not masked: %v", change)
+		}
+	}
+	if m := RedactMap(updated, keys...); m["auth"] != RedactedValue {
+		t.Fatalf("map not masked: %v", m)
+	}
+}
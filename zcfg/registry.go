@@ -0,0 +1,143 @@
+package zcfg
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// registryKey identifies a config by target type and instance name
+type registryKey struct {
+	typ  reflect.Type
+	name string
+}
+
+// Registry tracks loaded configs by target type and instance name.
+// Applications and tests can create their own registry to avoid sharing state.
+type Registry struct {
+	mu        sync.RWMutex
+	configs   map[registryKey]*Config
+	flags     map[string]*Config   // First config defining each flag, ordered by config name
+	flagNames map[*Config][]string // Flag names defined by each config
+}
+
+// defaultRegistry is used when no registry is set in options
+var defaultRegistry = NewRegistry()
+
+// NewRegistry creates a new empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		configs:   make(map[registryKey]*Config),
+		flags:     make(map[string]*Config),
+		flagNames: make(map[*Config][]string),
+	}
+}
+
+// DefaultRegistry returns the process-wide registry used by Get and GetNamed
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// GetNamed gets named Config instance by target type from the default registry
+func GetNamed[T any](name string) *Config {
+	return GetFrom[T](defaultRegistry, name)
+}
+
+// GetFrom gets named Config instance by target type from registry r
+func GetFrom[T any](r *Registry, name string) *Config {
+	var target *T
+	return r.lookup(reflect.TypeOf(target), name)
+}
+
+// lookup finds config by target pointer type and name
+func (r *Registry) lookup(typ reflect.Type, name string) *Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configs[registryKey{typ: typ, name: name}]
+}
+
+// register stores config under its target type and name, replacing and stopping any previous one
+func (r *Registry) register(c *Config) {
+	key := registryKey{typ: reflect.TypeOf(c.target), name: c.name}
+
+	c.mu.RLock()
+	names := c.flagNames()
+	c.mu.RUnlock()
+
+	r.mu.Lock()
+	previous := r.configs[key]
+	r.configs[key] = c
+	if previous != nil {
+		delete(r.flagNames, previous)
+	}
+	r.setFlagNames(c, names)
+	r.mu.Unlock()
+
+	c.setRegistry(r, nil)
+
+	if previous != nil && previous != c {
+		previous.setRegistry(nil, r)
+		_ = previous.StopWatcher()
+	}
+}
+
+// Unregister removes config from the registry and stops its watcher
+func (r *Registry) Unregister(c *Config) error {
+	if c == nil {
+		return nil
+	}
+
+	key := registryKey{typ: reflect.TypeOf(c.target), name: c.name}
+
+	r.mu.Lock()
+	if r.configs[key] == c {
+		delete(r.configs, key)
+		r.setFlagNames(c, nil)
+	}
+	r.mu.Unlock()
+
+	c.setRegistry(nil, r)
+
+	return c.StopWatcher()
+}
+
+// setRegistry sets the registry of config c to r. If from is set, it is only replaced if it is from.
+func (c *Config) setRegistry(r, from *Registry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if from == nil || c.registry == from {
+		c.registry = r
+	}
+}
+
+// Configs returns all configs in the registry
+func (r *Registry) Configs() []*Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Config, 0, len(r.configs))
+	for _, c := range r.configs {
+		result = append(result, c)
+	}
+	return result
+}
+
+// Close unregisters all configs and stops their watchers
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	configs := r.configs
+	r.configs = make(map[registryKey]*Config)
+	r.flags = make(map[string]*Config)
+	r.flagNames = make(map[*Config][]string)
+	r.mu.Unlock()
+
+	var errs []error
+	for _, c := range configs {
+		c.setRegistry(nil, r)
+		if err := c.StopWatcher(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
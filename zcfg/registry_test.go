@@ -0,0 +1,85 @@
+package zcfg
+
+import (
+	"sync"
+	"testing"
+)
+
+type registryConfigA struct {
+	Port int `meta:"port"`
+}
+
+type registryConfigB struct {
+	Port int `meta:"port"`
+}
+
+func TestRegistryKeyedByTypeAndName(t *testing.T) {
+	registry := NewRegistry()
+	raw := map[string]any{"port": 1}
+
+	a, _ := newTestConfig[registryConfigA](t, raw, WithRegistry(registry), WithName("main"))
+	b, _ := newTestConfig[registryConfigB](t, raw, WithRegistry(registry), WithName("main"))
+	other, _ := newTestConfig[registryConfigA](t, raw, WithRegistry(registry), WithName("other"))
+
+	if GetFrom[registryConfigA](registry, "main") != a || GetFrom[registryConfigB](registry, "main") != b {
+		t.Fatal("configs of different types with the same name are not kept apart")
+	}
+	if GetFrom[registryConfigA](registry, "other") != other {
+		t.Fatal("config with another name is not registered")
+	}
+	if GetFrom[registryConfigA](registry, "missing") != nil || GetFrom[registryConfigA](DefaultRegistry(), "main") != nil {
+		t.Fatal("lookup found an unregistered config")
+	}
+	if n := len(registry.Configs()); n != 3 {
+		t.Fatalf("expected 3 configs, got %d", n)
+	}
+
+	// A config with the same type and name replaces the previous one
+	replaced, _ := newTestConfig[registryConfigA](t, raw, WithRegistry(registry), WithName("main"))
+	if GetFrom[registryConfigA](registry, "main") != replaced || a.registry != nil {
+		t.Fatal("previous config is not replaced")
+	}
+	// Unregistering a replaced config leaves its successor registered
+	if err := registry.Unregister(a); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+	if GetFrom[registryConfigA](registry, "main") != replaced {
+		t.Fatal("unregistering a replaced config removed its successor")
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if GetFrom[registryConfigB](registry, "main") != nil || GetFrom[registryConfigA](registry, "main") != replaced {
+		t.Fatal("close removed the wrong config")
+	}
+
+	if err := registry.Close(); err != nil {
+		t.Fatalf("close registry: %v", err)
+	}
+	if len(registry.Configs()) != 0 || other.registry != nil || replaced.registry != nil {
+		t.Fatal("registry close left configs registered")
+	}
+}
+
+func TestRegistryConcurrentClose(t *testing.T) {
+	registry := NewRegistry()
+	config, _ := newTestConfig[registryConfigA](t, map[string]any{"port": 1}, WithRegistry(registry))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = config.Update(map[string]any{"port": i})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			registry.register(config)
+			_ = config.Close()
+		}
+	}()
+	wg.Wait()
+}
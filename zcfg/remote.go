@@ -0,0 +1,483 @@
+package zcfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RemoteOption represents remote source options
+type RemoteOption struct {
+	Client    *http.Client  // HTTP client, default with a 30 second timeout
+	Header    http.Header   // Extra request headers, e.g. Authorization
+	Format    Format        // Content format, default from Content-Type or the URL extension
+	Interval  time.Duration // Poll interval, in long-poll mode the delay after errors and requests the server did not hold
+	LongPoll  bool          // Whether to poll again immediately after held requests, for servers that hold requests until a change
+	PollWait  time.Duration // How long the server may hold a long-poll request, default 5 minutes
+	CacheFile string        // Last-known-good cache file used when the server is unreachable at load
+}
+
+const (
+	longPollMargin       = 30 * time.Second // Added to PollWait for the client timeout of long-poll requests
+	longPollHeldFraction = 10               // Long-poll responses faster than PollWait divided by this were not held
+)
+
+// NewRemoteOption creates a new RemoteOption with default values
+func NewRemoteOption() *RemoteOption {
+	return &RemoteOption{
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		Header:    nil,
+		Format:    "",
+		Interval:  30 * time.Second,
+		LongPoll:  false,
+		PollWait:  5 * time.Minute,
+		CacheFile: "",
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to fetch the config
+func WithHTTPClient(client *http.Client) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.Client = client
+	}
+}
+
+// WithHeader adds a request header, e.g. for authentication
+func WithHeader(key, value string) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		if o.Header == nil {
+			o.Header = make(http.Header)
+		}
+		o.Header.Add(key, value)
+	}
+}
+
+// WithFormat sets the content format instead of detecting it from the response
+func WithFormat(format Format) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.Format = format
+	}
+}
+
+// WithPollInterval sets the poll interval
+func WithPollInterval(interval time.Duration) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.Interval = interval
+	}
+}
+
+// WithLongPoll sets whether to poll again immediately after each held response.
+// The server is expected to hold requests with a matching If-None-Match until the config changes,
+// responses much faster than the poll wait are followed by the poll interval.
+func WithLongPoll(longPoll bool) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.LongPoll = longPoll
+	}
+}
+
+// WithPollWait sets how long the server may hold a long-poll request. It is sent in a
+// Prefer: wait header, and the client timeout of long-poll requests is raised to cover it.
+func WithPollWait(wait time.Duration) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.PollWait = wait
+	}
+}
+
+// WithCacheFile sets the last-known-good cache file, its format is chosen by extension.
+// The cache is written with mode 0600 and holds secret values unmasked.
+func WithCacheFile(file string) func(*RemoteOption) {
+	return func(o *RemoteOption) {
+		o.CacheFile = file
+	}
+}
+
+// RemoteSource fetches configuration from an HTTP URL using ETag caching
+type RemoteSource struct {
+	url    string
+	option *RemoteOption
+	etag   string
+	mu     sync.Mutex
+}
+
+// NewRemoteSource creates a new RemoteSource for url
+func NewRemoteSource(url string, opts ...func(*RemoteOption)) *RemoteSource {
+	option := NewRemoteOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	return &RemoteSource{
+		url:    url,
+		option: option,
+	}
+}
+
+// URL returns the URL the config is fetched from
+func (s *RemoteSource) URL() string {
+	return s.url
+}
+
+// Fetch fetches and parses the config. It returns a nil map without error if the
+// server reports the config unchanged since the last fetch.
+func (s *RemoteSource) Fetch(ctx context.Context) (map[string]any, error) {
+	m, etag, err := s.fetch(ctx, s.currentETag(), false)
+	if m != nil {
+		s.setETag(etag)
+	}
+	return m, err
+}
+
+// currentETag returns the ETag of the last config that was applied
+func (s *RemoteSource) currentETag() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.etag
+}
+
+// setETag records the ETag of the last config that was applied
+func (s *RemoteSource) setETag(etag string) {
+	s.mu.Lock()
+	s.etag = etag
+	s.mu.Unlock()
+}
+
+// fetch fetches and parses the config and returns it with its ETag. It returns a nil map
+// without error if the server reports the config unchanged since etag.
+// Long-poll requests ask the server to wait up to PollWait for a change.
+func (s *RemoteSource) fetch(ctx context.Context, etag string, longPoll bool) (map[string]any, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request for %s: %w", s.url, err)
+	}
+	for key, values := range s.option.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	client := s.option.Client
+	if longPoll && s.option.PollWait > 0 {
+		req.Header.Set("Prefer", fmt.Sprintf("wait=%d", int(s.option.PollWait.Seconds())))
+
+		// The server holds the request, so the client must not time out first
+		if timeout := s.option.PollWait + longPollMargin; client.Timeout > 0 && client.Timeout < timeout {
+			longPollClient := *client
+			longPollClient.Timeout = timeout
+			client = &longPollClient
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config from %s: %w", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, "", nil
+	default:
+		return nil, "", fmt.Errorf("failed to fetch config from %s: %s", s.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config from %s: %w", s.url, err)
+	}
+
+	format, err := s.format(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, "", err
+	}
+	m, err := ParseBytes(data, format)
+	if err != nil {
+		return nil, "", err
+	}
+	if m == nil {
+		m = make(map[string]any)
+	}
+
+	return m, resp.Header.Get("ETag"), nil
+}
+
+// format returns the content format from the option, the Content-Type or the URL extension
+func (s *RemoteSource) format(contentType string) (Format, error) {
+	if s.option.Format != "" {
+		return s.option.Format, nil
+	}
+
+	contentType = strings.ToLower(contentType)
+	switch {
+	case strings.Contains(contentType, "json"):
+		return FormatJSON, nil
+	case strings.Contains(contentType, "yaml"), strings.Contains(contentType, "yml"):
+		return FormatYAML, nil
+	case strings.Contains(contentType, "toml"):
+		return FormatTOML, nil
+	}
+
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return "", fmt.Errorf("invalid config URL %s: %w", s.url, err)
+	}
+	format, err := FormatFromExt(u.Path)
+	if err != nil {
+		return "", fmt.Errorf("cannot detect format of %s, content type '%s'", s.url, contentType)
+	}
+	return format, nil
+}
+
+// cacheFormat returns the format of the cache file, JSON if its extension is unknown
+func (s *RemoteSource) cacheFormat() Format {
+	format, err := FormatFromExt(s.option.CacheFile)
+	if err != nil {
+		return FormatJSON
+	}
+	return format
+}
+
+// loadCache reads the last-known-good cache file
+func (s *RemoteSource) loadCache() (map[string]any, error) {
+	data, err := os.ReadFile(s.option.CacheFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache file %s: %w", s.option.CacheFile, err)
+	}
+	return ParseBytes(data, s.cacheFormat())
+}
+
+// saveCache atomically writes m to the cache file if one is configured
+func (s *RemoteSource) saveCache(m map[string]any) error {
+	if s.option.CacheFile == "" {
+		return nil
+	}
+
+	data, err := Marshal(m, s.cacheFormat())
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.option.CacheFile), filepath.Base(s.option.CacheFile)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache file %s: %w", s.option.CacheFile, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file %s: %w", s.option.CacheFile, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file %s: %w", s.option.CacheFile, err)
+	}
+	if err := os.Rename(tmp.Name(), s.option.CacheFile); err != nil {
+		return fmt.Errorf("failed to write cache file %s: %w", s.option.CacheFile, err)
+	}
+	return nil
+}
+
+// LoadRemote loads configuration from a remote source. If the server is unreachable
+// the last-known-good cache file is used. With HotReload and Updatable the source is
+// polled and changes are applied like file reloads.
+func LoadRemote[T any](source *RemoteSource, opts ...func(*Option)) (*T, error) {
+	var fetched map[string]any
+	var etag string
+	config, err := New[T](func(v *Config) error {
+		// Fetch unconditionally, the source may have been used before
+		rawMap, fetchedETag, err := source.fetch(context.Background(), "", false)
+		if err != nil {
+			if source.option.CacheFile == "" {
+				return err
+			}
+			cached, cacheErr := source.loadCache()
+			if cacheErr != nil {
+				return errors.Join(err, cacheErr)
+			}
+			v.option.logger().Warn().Err(err).Str("cache", source.option.CacheFile).Msg("remote config unavailable, using cache")
+			rawMap = cached
+		} else {
+			fetched, etag = rawMap, fetchedETag
+		}
+		v.rawMap = rawMap
+		v.remote = source
+		return nil
+	}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the ETag once the config is applied, so a failed load is fetched again
+	if fetched != nil {
+		source.setETag(etag)
+		if err := source.saveCache(fetched); err != nil {
+			config.option.logger().Warn().Err(err).Msg("failed to save remote config cache")
+		}
+	}
+
+	return config.target.(*T), nil
+}
+
+// RemotePoller polls a remote source for hot reload
+type RemotePoller struct {
+	source  *RemoteSource
+	config  *Config
+	stopCh  chan struct{}
+	cancel  context.CancelFunc
+	running bool
+	mu      sync.RWMutex
+}
+
+// NewRemotePoller creates a new remote poller for the config's remote source
+func NewRemotePoller(c *Config) (*RemotePoller, error) {
+	if c.remote == nil {
+		return nil, fmt.Errorf("config has no remote source")
+	}
+
+	return &RemotePoller{
+		source: c.remote,
+		config: c,
+	}, nil
+}
+
+// Start starts polling
+func (p *RemotePoller) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stopCh = make(chan struct{})
+	p.cancel = cancel
+	p.running = true
+
+	go p.pollLoop(ctx, p.stopCh)
+
+	return nil
+}
+
+// Stop stops polling and cancels a pending request
+func (p *RemotePoller) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return nil
+	}
+
+	p.running = false
+	p.cancel()
+	close(p.stopCh)
+
+	return nil
+}
+
+// IsRunning returns whether the poller is running
+func (p *RemotePoller) IsRunning() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.running
+}
+
+// pollLoop is the main poll loop
+func (p *RemotePoller) pollLoop(ctx context.Context, stopCh chan struct{}) {
+	delay := p.source.option.Interval
+	if !p.source.option.LongPoll {
+		delay = p.wait(stopCh, delay)
+	}
+
+	for delay >= 0 {
+		start := time.Now()
+		err := p.poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Poll again immediately only if the server held the request,
+		// servers ignoring the wait would otherwise be polled in a busy loop
+		delay = p.source.option.Interval
+		wait := p.source.option.PollWait
+		if err == nil && p.source.option.LongPoll && wait > 0 && time.Since(start) >= wait/longPollHeldFraction {
+			delay = 0
+		}
+		delay = p.wait(stopCh, delay)
+	}
+}
+
+// wait waits for delay and returns it, or -1 if the poller was stopped
+func (p *RemotePoller) wait(stopCh chan struct{}, delay time.Duration) time.Duration {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-stopCh:
+		return -1
+	case <-timer.C:
+		return delay
+	}
+}
+
+// poll fetches the remote config once and applies it if it changed
+func (p *RemotePoller) poll(ctx context.Context) error {
+	m, etag, err := p.source.fetch(ctx, p.source.currentETag(), p.source.option.LongPoll)
+	if err == nil && m == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err == nil {
+		err = p.config.reloadRemote(m)
+	}
+
+	p.config.recordReload(p.source.url, err)
+	if err != nil {
+		p.config.option.logger().Error().Err(err).Str("url", p.source.url).Msg("remote config reload failed")
+		return err
+	}
+
+	// Record the ETag only once the config is applied, so rejected configs are fetched again
+	p.source.setETag(etag)
+	if err := p.source.saveCache(m); err != nil {
+		p.config.option.logger().Warn().Err(err).Msg("failed to save remote config cache")
+	}
+	return nil
+}
+
+// reloadRemote applies a changed remote config through the reload path
+func (c *Config) reloadRemote(m map[string]any) error {
+	env, err := loadEnvFiles(c.option)
+	if err != nil {
+		return err
+	}
+	composed, positions, err := c.composeSources(m, nil, env)
+	if err != nil {
+		return err
+	}
+
+	source := Provenance{Kind: SourceRemote, File: c.remote.url, Time: time.Now()}
+	if err := c.applyReload(composed, positions, env, source); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.base = m
+	c.basePos = nil
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,144 @@
+package zcfg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type remoteConfig struct {
+	Port int `meta:"port"`
+}
+
+// remoteServer serves body with etag and records the If-None-Match headers it receives
+type remoteServer struct {
+	mu      sync.Mutex
+	body    string
+	etag    string
+	delay   time.Duration
+	matches []string
+	prefer  string
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	body, etag, delay := s.body, s.etag, s.delay
+	s.matches = append(s.matches, r.Header.Get("If-None-Match"))
+	s.prefer = r.Header.Get("Prefer")
+	s.mu.Unlock()
+
+	time.Sleep(delay)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(body))
+}
+
+func (s *remoteServer) set(body, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag = body, etag
+}
+
+func TestRemotePollRetriesRejectedConfig(t *testing.T) {
+	server := &remoteServer{body: `{"port":80}`, etag: "v1"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source := NewRemoteSource(ts.URL)
+	registry := NewRegistry()
+	target, err := LoadRemote[remoteConfig](source, WithUseEnv(false), WithUpdatable(true), WithRegistry(registry))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	poller, err := NewRemotePoller(GetFrom[remoteConfig](registry, ""))
+	if err != nil {
+		t.Fatalf("poller: %v", err)
+	}
+
+	// Unchanged config
+	if err := poller.poll(context.Background()); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+
+	// A rejected config keeps the last applied ETag so it is fetched again
+	server.set(`{"port":"abc"}`, "v2")
+	if err := poller.poll(context.Background()); err == nil {
+		t.Fatal("expected reload error")
+	}
+	server.set(`{"port":81}`, "v2")
+	if err := poller.poll(context.Background()); err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	if target.Port != 81 {
+		t.Fatalf("expected port 81, got %d", target.Port)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	want := []string{"", "v1", "v1", "v1"}
+	if len(server.matches) != len(want) {
+		t.Fatalf("unexpected requests: %q", server.matches)
+	}
+	for i := range want {
+		if server.matches[i] != want[i] {
+			t.Fatalf("unexpected If-None-Match headers: %q", server.matches)
+		}
+	}
+}
+
+func TestRemoteLongPollOutlastsClientTimeout(t *testing.T) {
+	server := &remoteServer{body: `{"port":80}`, etag: "v1", delay: 200 * time.Millisecond}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	source := NewRemoteSource(ts.URL, WithHTTPClient(client), WithLongPoll(true), WithPollWait(time.Second))
+
+	if _, _, err := source.fetch(context.Background(), "", false); err == nil {
+		t.Fatal("expected client timeout for a regular request")
+	}
+	if _, _, err := source.fetch(context.Background(), "", true); err != nil {
+		t.Fatalf("long poll: %v", err)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.prefer != "wait=1" {
+		t.Fatalf("unexpected Prefer header %q", server.prefer)
+	}
+}
+
+func TestRemoteLongPollBacksOffWithoutHold(t *testing.T) {
+	server := &remoteServer{body: `{"port":80}`, etag: "v1"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	source := NewRemoteSource(ts.URL, WithLongPoll(true), WithPollWait(time.Second), WithPollInterval(100*time.Millisecond))
+	registry := NewRegistry()
+	if _, err := LoadRemote[remoteConfig](source, WithUseEnv(false), WithUpdatable(true), WithRegistry(registry)); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	poller, err := NewRemotePoller(GetFrom[remoteConfig](registry, ""))
+	if err != nil {
+		t.Fatalf("poller: %v", err)
+	}
+
+	// The server answers 304 immediately instead of holding the request
+	if err := poller.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	time.Sleep(350 * time.Millisecond)
+	_ = poller.Stop()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if requests := len(server.matches); requests > 6 {
+		t.Fatalf("server that does not hold requests was polled %d times", requests)
+	}
+}
@@ -0,0 +1,314 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// schemaTypes maps type names used in schema files to Go types
+var schemaTypes = map[string]reflect.Type{
+	"string":   reflect.TypeOf(""),
+	"bool":     reflect.TypeOf(false),
+	"int":      reflect.TypeOf(int(0)),
+	"int8":     reflect.TypeOf(int8(0)),
+	"int16":    reflect.TypeOf(int16(0)),
+	"int32":    reflect.TypeOf(int32(0)),
+	"int64":    reflect.TypeOf(int64(0)),
+	"uint":     reflect.TypeOf(uint(0)),
+	"uint8":    reflect.TypeOf(uint8(0)),
+	"uint16":   reflect.TypeOf(uint16(0)),
+	"uint32":   reflect.TypeOf(uint32(0)),
+	"uint64":   reflect.TypeOf(uint64(0)),
+	"float32":  reflect.TypeOf(float32(0)),
+	"float64":  reflect.TypeOf(float64(0)),
+	"duration": reflect.TypeOf(time.Duration(0)),
+	"bytesize": byteSizeType,
+	"rate":     rateType,
+	"flags":    flagsType,
+	"any":      reflect.TypeOf((*any)(nil)).Elem(),
+}
+
+// Reserved keys of nested schema maps
+const (
+	schemaOptionsKey = "$options" // Tag options of the struct or map field
+	schemaValuesKey  = "$values"  // Schema of the values of a map field
+)
+
+// ValidateSchema validates raw map m against a schema map.
+//
+// The schema mirrors the config layout. Leaf values are a type name followed by
+// the same options as the meta tag, e.g.
+//
+//	server:
+//	  port: "int,range=[1:65535]"
+//	  mode: "string,options=dev|prod,default=dev"
+//	  timeout: "duration,optional"
+//	tags: "[]string,optional"
+//	upstreams:
+//	  - host: "string"
+//	    weight: "int,default=1"
+//
+// Nested maps describe structs, a list with a single map element describes a list of structs.
+// The tag options of a nested map are set with the key $options, a map with the key $values
+// describes a map of string keys to values of that schema, e.g.
+//
+//	pools:
+//	  $options: optional
+//	  $values:
+//	    size: "int,default=10"
+//
+// Types are string, bool, int*, uint*, float32, float64, duration, bytesize, rate, flags, any,
+// optionally prefixed with [] or map[string].
+func ValidateSchema(m map[string]any, schema map[string]any, opts ...func(*Option)) error {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	schemaType, err := schemaStructType(schema, option, "")
+	if err != nil {
+		return err
+	}
+
+	target := reflect.New(schemaType)
+	ctx := newMapContext(option, Provenance{Kind: SourceData})
+	if err := mapToStruct(m, target.Interface(), ctx, false); err != nil {
+		return err
+	}
+
+	_, err = ctx.checkUnknownKeys()
+	return err
+}
+
+// schemaStructType builds a struct type from a schema map
+func schemaStructType(schema map[string]any, option *Option, path string) (reflect.Type, error) {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]reflect.StructField, 0, len(keys))
+	for i, key := range keys {
+		if key == schemaOptionsKey {
+			continue
+		}
+		fieldPath := joinPath(path, key)
+
+		fieldType, tagOptions, err := schemaFieldType(schema[key], option, fieldPath)
+		if err != nil {
+			return nil, err
+		}
+
+		tag := key
+		if tagOptions != "" {
+			tag += "," + tagOptions
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: fieldType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`%s:%q`, option.TagName, tag)),
+		})
+	}
+
+	return reflect.StructOf(fields), nil
+}
+
+// schemaFieldType returns the field type and tag options for a schema value
+func schemaFieldType(value any, option *Option, path string) (reflect.Type, string, error) {
+	if m, ok := toStringMap(value); ok {
+		var tagOptions string
+		if options, exists := m[schemaOptionsKey]; exists {
+			if tagOptions, ok = options.(string); !ok {
+				return nil, "", fmt.Errorf("schema %s %s expected string, got %T", path, schemaOptionsKey, options)
+			}
+		}
+		if values, exists := m[schemaValuesKey]; exists {
+			for key := range m {
+				if key != schemaValuesKey && key != schemaOptionsKey {
+					return nil, "", fmt.Errorf("schema %s map with %s cannot have other keys", path, schemaValuesKey)
+				}
+			}
+			elemType, _, err := schemaFieldType(values, option, path+"[]")
+			if err != nil {
+				return nil, "", err
+			}
+			return reflect.MapOf(reflect.TypeOf(""), elemType), tagOptions, nil
+		}
+		t, err := schemaStructType(m, option, path)
+		return t, tagOptions, err
+	}
+
+	if s, ok := toSlice(value); ok {
+		if len(s) != 1 {
+			return nil, "", fmt.Errorf("schema %s list must have exactly one element", path)
+		}
+		elemType, tagOptions, err := schemaFieldType(s[0], option, path+"[]")
+		if err != nil {
+			return nil, "", err
+		}
+		return reflect.SliceOf(elemType), tagOptions, nil
+	}
+
+	spec, ok := value.(string)
+	if !ok {
+		return nil, "", fmt.Errorf("schema %s expected type string, got %T", path, value)
+	}
+
+	typeName, tagOptions, _ := strings.Cut(spec, ",")
+	t, err := schemaTypeByName(strings.TrimSpace(typeName))
+	if err != nil {
+		return nil, "", fmt.Errorf("schema %s %w", path, err)
+	}
+	return t, tagOptions, nil
+}
+
+// schemaTypeByName resolves type names like int, []string or map[string]duration
+func schemaTypeByName(name string) (reflect.Type, error) {
+	switch {
+	case strings.HasPrefix(name, "[]"):
+		elemType, err := schemaTypeByName(strings.TrimPrefix(name, "[]"))
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elemType), nil
+	case strings.HasPrefix(name, "map[string]"):
+		elemType, err := schemaTypeByName(strings.TrimPrefix(name, "map[string]"))
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(reflect.TypeOf(""), elemType), nil
+	}
+
+	t, exists := schemaTypes[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown type '%s'", name)
+	}
+	return t, nil
+}
+
+// SchemaOf returns the schema of struct v in the format accepted by ValidateSchema
+func SchemaOf(v any, opts ...func(*Option)) map[string]any {
+	option := NewOption()
+	for _, opt := range opts {
+		opt(option)
+	}
+
+	t := indirectType(reflect.TypeOf(v))
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	schema := make(map[string]any)
+	schemaFields(t, option, schema)
+	return schema
+}
+
+// Schema returns the schema of the config target type
+func (c *Config) Schema() map[string]any {
+	return SchemaOf(c.target, WithTagName(c.option.TagName))
+}
+
+// schemaFields adds the schema of each field of struct type t to schema, embedded structs are flattened
+func schemaFields(t reflect.Type, option *Option, schema map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tag := fieldTag(t, fieldType, option)
+		tagInfo := parseTag(tag)
+		if tagInfo.Skip {
+			continue
+		}
+
+		if fieldType.Anonymous && isStructType(fieldType.Type) {
+			schemaFields(indirectType(fieldType.Type), option, schema)
+			continue
+		}
+
+		_, tagOptions, _ := strings.Cut(tag, ",")
+		schema[fieldKey(fieldType, tagInfo, option)] = schemaOfType(fieldType.Type, option, tagOptions)
+	}
+}
+
+// schemaOfType returns the schema value for type t with tag options
+func schemaOfType(t reflect.Type, option *Option, tagOptions string) any {
+	t = indirectType(t)
+
+	switch {
+	case !hasStructSchema(t):
+	case isStructType(t):
+		nested := make(map[string]any)
+		schemaFields(t, option, nested)
+		if tagOptions != "" {
+			nested[schemaOptionsKey] = tagOptions
+		}
+		return nested
+	case t.Kind() == reflect.Slice:
+		return []any{schemaOfType(t.Elem(), option, tagOptions)}
+	case t.Kind() == reflect.Map:
+		nested := map[string]any{schemaValuesKey: schemaOfType(t.Elem(), option, "")}
+		if tagOptions != "" {
+			nested[schemaOptionsKey] = tagOptions
+		}
+		return nested
+	}
+
+	spec := schemaTypeName(t)
+	if tagOptions != "" {
+		spec += "," + tagOptions
+	}
+	return spec
+}
+
+// hasStructSchema checks if type t is described by nested schema maps, i.e. it is a struct
+// or a slice or string-keyed map of them. Flags sections have a type name.
+func hasStructSchema(t reflect.Type) bool {
+	t = indirectType(t)
+	switch {
+	case t == flagsType:
+		return false
+	case isStructType(t):
+		return true
+	case t.Kind() == reflect.Slice:
+		return hasStructSchema(t.Elem())
+	case t.Kind() == reflect.Map:
+		return t.Key().Kind() == reflect.String && hasStructSchema(t.Elem())
+	default:
+		return false
+	}
+}
+
+// schemaTypeName returns the schema type name for type t
+func schemaTypeName(t reflect.Type) string {
+	t = indirectType(t)
+	for name, schemaType := range schemaTypes {
+		if t == schemaType {
+			return name
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return "[]" + schemaTypeName(t.Elem())
+	case reflect.Map:
+		return "map[string]" + schemaTypeName(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t.Kind().String()
+	case reflect.Struct:
+		return "map[string]any"
+	default:
+		return "any"
+	}
+}
@@ -0,0 +1,88 @@
+package zcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type schemaPool struct {
+	Size  int    `meta:"size,default=10,range=[1:100]"`
+	Label string // Untagged, keyed like the mapper
+}
+
+type schemaTLS struct {
+	Cert string `meta:"cert"`
+	Key  string `meta:"key,secret"`
+}
+
+type schemaConfig struct {
+	Name  string                `meta:"name"`
+	TLS   *schemaTLS            `meta:"tls,optional"`
+	Pools map[string]schemaPool `meta:"pools,optional"`
+	Hosts []schemaPool          `meta:"hosts,optional"`
+	Flags Flags                 `meta:"flags,optional"`
+}
+
+func TestSchemaRoundTrip(t *testing.T) {
+	schema := SchemaOf(schemaConfig{})
+
+	tls, ok := schema["tls"].(map[string]any)
+	if !ok || tls[schemaOptionsKey] != "optional" || tls["key"] != "string,secret" {
+		t.Fatalf("unexpected tls schema: %#v", schema["tls"])
+	}
+	pools, ok := schema["pools"].(map[string]any)
+	if !ok || pools[schemaOptionsKey] != "optional" {
+		t.Fatalf("unexpected pools schema: %#v", schema["pools"])
+	}
+	if pool, ok := pools[schemaValuesKey].(map[string]any); !ok || pool["size"] != "int,default=10,range=[1:100]" || pool["label"] != "string" {
+		t.Fatalf("unexpected pool schema: %#v", pools[schemaValuesKey])
+	}
+	if schema["flags"] != "flags,optional" {
+		t.Fatalf("unexpected flags schema: %#v", schema["flags"])
+	}
+
+	tests := []struct {
+		name string
+		raw  map[string]any
+		err  string
+	}{
+		{name: "required only", raw: map[string]any{"name": "a"}},
+		{
+			name: "full",
+			raw: map[string]any{
+				"name":  "a",
+				"tls":   map[string]any{"cert": "c", "key": "k"},
+				"pools": map[string]any{"main": map[string]any{"size": 5, "label": "m"}, "spare": map[string]any{"label": "s"}},
+				"hosts": []any{map[string]any{"size": 1, "label": "h"}},
+				"flags": map[string]any{"dark-mode": true, "beta": map[string]any{"enabled": true, "rollout": 10}},
+			},
+		},
+		{name: "missing required", raw: map[string]any{}, err: "name"},
+		{name: "list element required", raw: map[string]any{"name": "a", "hosts": []any{map[string]any{}}}, err: "hosts[0]"},
+		{name: "map value range", raw: map[string]any{"name": "a", "pools": map[string]any{"main": map[string]any{"size": 500, "label": "m"}}}, err: "size"},
+		{name: "flag rollout range", raw: map[string]any{"name": "a", "flags": map[string]any{"beta": map[string]any{"rollout": 200}}}, err: "rollout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The config type itself accepts exactly what its schema accepts
+			_, loadErr := loadTestConfig[schemaConfig](tt.raw)
+			err := ValidateSchema(tt.raw, schema)
+			if tt.err == "" {
+				if loadErr != nil || err != nil {
+					t.Fatalf("expected valid, got load %v, schema %v", loadErr, err)
+				}
+				return
+			}
+			if loadErr == nil || err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got load %v, schema %v", tt.err, loadErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaValuesKey(t *testing.T) {
+	schema := map[string]any{"pools": map[string]any{schemaValuesKey: "int", "size": "int"}}
+	if err := ValidateSchema(map[string]any{}, schema); err == nil || !strings.Contains(err.Error(), schemaValuesKey) {
+		t.Fatalf("expected error for keys next to %s, got %v", schemaValuesKey, err)
+	}
+}
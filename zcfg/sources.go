@@ -0,0 +1,88 @@
+package zcfg
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// applyOverlays deep merges existing overlay files over m in order
+func applyOverlays(m map[string]any, positions positionIndex, overlays []string) (map[string]any, positionIndex, error) {
+	for _, overlay := range overlays {
+		overlayMap, overlayPositions, err := readConfigFile(nil, overlay)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		m = MergeMaps(m, overlayMap)
+		positions = positions.merge(overlayMap, overlayPositions)
+	}
+	return m, positions, nil
+}
+
+// readSources reads the config file again and composes it with env
+func (c *Config) readSources(env *envLookup) (map[string]any, positionIndex, error) {
+	c.mu.RLock()
+	base, positions := c.base, c.basePos
+	c.mu.RUnlock()
+
+	if c.file != "" {
+		var err error
+		base, positions, err = readConfigFile(c.fsys, c.file)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return c.composeSources(base, positions, env)
+}
+
+// composeSources merges the active profile and the overlays over base and renders templates with env.
+// It returns the composed map with the positions of its keys in the files they were read from.
+func (c *Config) composeSources(base map[string]any, positions positionIndex, env *envLookup) (map[string]any, positionIndex, error) {
+	m, positions, err := c.applyProfile(base, positions)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, positions, err = applyOverlays(m, positions, c.option.Overlays)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !c.option.Templates {
+		return m, positions, nil
+	}
+	m, err = renderTemplates(m, c.option, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, positions, nil
+}
+
+// watchPaths returns the config, overlay and env files on the real filesystem a hot reload watches
+func (c *Config) watchPaths() []string {
+	var paths []string
+	if c.file != "" && c.fsys == nil {
+		paths = append(paths, c.file)
+		if c.profile != "" {
+			if profileFile := profileFileName(c.file, c.profile); fileExists(profileFile) {
+				paths = append(paths, profileFile)
+			}
+		}
+	}
+	for _, overlay := range c.option.Overlays {
+		if fileExists(overlay) {
+			paths = append(paths, overlay)
+		}
+	}
+	if c.env != nil {
+		paths = append(paths, c.env.paths...)
+	}
+	return paths
+}
+
+// fileExists checks if a file exists on the real filesystem
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,264 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// StaticPolicy controls how updates and reloads that change static fields are handled
+type StaticPolicy int
+
+const (
+	StaticReject  StaticPolicy = iota // Reject the whole update
+	StaticRestart                     // Apply the update without the static fields and report them as requiring a restart
+)
+
+// RestartCallback is called with the static field changes of an update, secret values are masked
+type RestartCallback func(changes []Change)
+
+// RestartRequiredError is returned when an update changes static fields with StaticReject
+type RestartRequiredError struct {
+	Changes []Change
+}
+
+// Error implements error
+func (e *RestartRequiredError) Error() string {
+	return fmt.Sprintf("static fields cannot change without a restart: %s", changePaths(e.Changes))
+}
+
+// changePaths returns the comma-separated paths of changes
+func changePaths(changes []Change) string {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.Path
+	}
+	return strings.Join(paths, ", ")
+}
+
+// staticChanges returns the changes of static fields from current to updated, sorted by path
+func staticChanges(current, updated reflect.Value, ctx *mapContext) []Change {
+	var changes []Change
+	walkStatic(current, updated, ctx, "", false, func(path string, current, updated reflect.Value, secret bool) {
+		if reflect.DeepEqual(current.Interface(), updated.Interface()) {
+			return
+		}
+
+		change := Change{Path: path, Kind: ChangeModified, Old: indirectInterface(current), New: indirectInterface(updated)}
+		switch {
+		case change.Old == nil:
+			change.Kind = ChangeAdded
+		case change.New == nil:
+			change.Kind = ChangeRemoved
+		}
+		if secret {
+			change.Old, change.New = RedactedValue, RedactedValue
+		}
+		changes = append(changes, change)
+	})
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// restoreStatic sets the static fields of updated back to their current values
+func restoreStatic(current, updated reflect.Value, ctx *mapContext) {
+	walkStatic(current, updated, ctx, "", false, func(_ string, current, updated reflect.Value, _ bool) {
+		updated.Set(cloneValue(current))
+	})
+}
+
+// walkStatic calls fn for each static field of the structs current and updated.
+// Structs that are new in updated are compared with their defaults.
+func walkStatic(current, updated reflect.Value, ctx *mapContext, basePath string, secret bool, fn func(path string, current, updated reflect.Value, secret bool)) {
+	switch updated.Kind() {
+	case reflect.Ptr:
+		if updated.IsNil() {
+			return
+		}
+		if current.IsNil() {
+			current = defaultValue(updated.Type(), ctx)
+		}
+		walkStatic(current.Elem(), updated.Elem(), ctx, basePath, secret, fn)
+		return
+
+	case reflect.Interface:
+		if updated.IsNil() {
+			return
+		}
+		elem := updated.Elem()
+		currentElem := defaultValue(elem.Type(), ctx)
+		if !current.IsNil() && current.Elem().Type() == elem.Type() {
+			currentElem = current.Elem()
+		}
+		if elem.Kind() == reflect.Ptr || !updated.CanSet() {
+			walkStatic(currentElem, elem, ctx, basePath, secret, fn)
+			return
+		}
+
+		// Structs stored by value in an interface are walked on a copy that is stored back
+		copied := reflect.New(elem.Type()).Elem()
+		copied.Set(elem)
+		walkStatic(currentElem, copied, ctx, basePath, secret, fn)
+		updated.Set(copied)
+		return
+	}
+	if updated.Kind() != reflect.Struct || isUnitType(updated.Type()) || !updated.CanAddr() {
+		return
+	}
+	option := ctx.option
+
+	t := current.Type()
+	for i := 0; i < current.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+		if fieldType.Anonymous {
+			walkStatic(current.Field(i), updated.Field(i), ctx, basePath, secret, fn)
+			continue
+		}
+
+		fieldName := fieldType.Name
+		if tagInfo.FieldName != "" {
+			fieldName = tagInfo.FieldName
+		}
+		fieldPath := joinPath(basePath, fieldName)
+		fieldSecret := secret || tagInfo.Secret || isSecretType(fieldType.Type)
+
+		switch {
+		case tagInfo.Static:
+			fn(fieldPath, current.Field(i), updated.Field(i), fieldSecret)
+		case isStructType(fieldType.Type) || fieldType.Type.Kind() == reflect.Interface:
+			walkStatic(current.Field(i), updated.Field(i), ctx, fieldPath, fieldSecret, fn)
+		}
+	}
+}
+
+// defaultValue returns a new struct or struct pointer of type t with the defaults of its fields
+func defaultValue(t reflect.Type, ctx *mapContext) reflect.Value {
+	ptr := reflect.New(indirectType(t))
+	if isStructType(ptr.Type()) {
+		defaults := newMapContext(ctx.option, ctx.source)
+		defaults.env = ctx.env
+		_ = mapToStructWithPath(make(map[string]any), ptr.Interface(), defaults, "", false, true)
+	}
+	if t.Kind() == reflect.Ptr {
+		return ptr
+	}
+	return ptr.Elem()
+}
+
+// checkStaticFields returns an error for static fields in slice, array and map elements of type t,
+// elements can be added and removed so their static fields can't keep their values
+func checkStaticFields(t reflect.Type, option *Option, basePath string, inElement bool, seen map[reflect.Type]bool) error {
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return checkStaticFields(t.Elem(), option, basePath+"[]", true, seen)
+	case reflect.Struct:
+	default:
+		return nil
+	}
+	if isUnitType(t) || seen[t] {
+		return nil
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldType := t.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+
+		tagInfo := parseTag(fieldTag(t, fieldType, option))
+		if tagInfo.Skip {
+			continue
+		}
+
+		fieldPath := basePath
+		if !fieldType.Anonymous {
+			fieldName := fieldType.Name
+			if tagInfo.FieldName != "" {
+				fieldName = tagInfo.FieldName
+			}
+			fieldPath = joinPath(basePath, fieldName)
+		}
+		if tagInfo.Static && inElement {
+			return fmt.Errorf("field %s static is not supported in slice and map elements", fieldPath)
+		}
+		if err := checkStaticFields(fieldType.Type, option, fieldPath, inElement, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indirectInterface returns the value v points to, or nil for nil pointers
+func indirectInterface(v reflect.Value) any {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// dropRecords removes the provenance and presence recorded for path and below
+func (ctx *mapContext) dropRecords(path string) {
+	for p := range ctx.provenance {
+		if isSubPath(p, path) {
+			delete(ctx.provenance, p)
+		}
+	}
+	for p := range ctx.present {
+		if isSubPath(p, path) {
+			delete(ctx.present, p)
+		}
+	}
+	ctx.replaced = slices.DeleteFunc(ctx.replaced, func(p string) bool {
+		return isSubPath(p, path)
+	})
+}
+
+// mergeRestart merges the static changes of an update into the pending restart changes.
+// Pending changes of static fields the update set to their current values are dropped.
+func mergeRestart(pending map[string]Change, changes []Change, ctx *mapContext) map[string]Change {
+	result := make(map[string]Change, len(pending)+len(changes))
+	for path, change := range pending {
+		if _, set := ctx.provenance[path]; !set && !ctx.present[path] {
+			result[path] = change
+		}
+	}
+	for _, change := range changes {
+		result[change.Path] = change
+	}
+	return result
+}
+
+// RestartRequired returns the static field changes applied by updates with StaticRestart
+// that take effect after a restart, sorted by path. Secret values are masked.
+func (c *Config) RestartRequired() []Change {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	changes := make([]Change, 0, len(c.restart))
+	for _, change := range c.restart {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
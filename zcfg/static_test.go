@@ -0,0 +1,131 @@
+package zcfg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type staticConfig struct {
+	Listen string `meta:"listen,static"`
+	Level  string `meta:"level"`
+}
+
+func (c *staticConfig) Normalize() {
+	c.Listen = strings.ToLower(c.Listen)
+}
+
+func loadStatic(t *testing.T, policy StaticPolicy, callback RestartCallback) *Config {
+	t.Helper()
+	config, _ := newTestConfig[staticConfig](t, map[string]any{"listen": "Localhost:80", "level": "info"},
+		WithStaticPolicy(policy), WithRestartCallback(callback))
+	return config
+}
+
+func TestStaticReject(t *testing.T) {
+	var reported []Change
+	config := loadStatic(t, StaticReject, func(changes []Change) { reported = changes })
+
+	// Normalize makes the listen value of the update equal to the current one
+	if err := config.Update(map[string]any{"listen": "LOCALHOST:80", "level": "debug"}); err != nil {
+		t.Fatalf("update with normalized static value rejected: %v", err)
+	}
+
+	err := config.Update(map[string]any{"listen": "localhost:81", "level": "warn"})
+	var restartErr *RestartRequiredError
+	if !errors.As(err, &restartErr) || len(restartErr.Changes) != 1 || restartErr.Changes[0].Path != "listen" {
+		t.Fatalf("expected restart required error for listen, got %v", err)
+	}
+	if len(reported) != 1 {
+		t.Fatalf("restart callback not called: %v", reported)
+	}
+	if target := config.target.(*staticConfig); target.Level != "debug" || target.Listen != "localhost:80" {
+		t.Fatalf("rejected update was applied: %+v", target)
+	}
+}
+
+func TestStaticRestart(t *testing.T) {
+	var reported []Change
+	config := loadStatic(t, StaticRestart, func(changes []Change) { reported = changes })
+
+	if err := config.Update(map[string]any{"listen": "LOCALHOST:80"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(reported) != 0 || len(config.RestartRequired()) != 0 {
+		t.Fatalf("normalized static value reported as change: %v", reported)
+	}
+
+	if err := config.Update(map[string]any{"listen": "Localhost:81", "level": "warn"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if target := config.target.(*staticConfig); target.Level != "warn" || target.Listen != "localhost:80" {
+		t.Fatalf("unexpected target %+v", target)
+	}
+	pending := config.RestartRequired()
+	if len(pending) != 1 || pending[0].New != "localhost:81" || len(reported) != 1 {
+		t.Fatalf("unexpected restart changes %v, reported %v", pending, reported)
+	}
+}
+
+func TestStaticInElementsRejected(t *testing.T) {
+	type upstream struct {
+		Addr string `meta:"addr,static"`
+	}
+	type config struct {
+		Upstreams []upstream `meta:"upstreams"`
+	}
+
+	_, err := loadTestConfig[config](map[string]any{"upstreams": []any{map[string]any{"addr": "a:80"}}})
+	if err == nil || !strings.Contains(err.Error(), "upstreams[].addr") {
+		t.Fatalf("expected static error, got %v", err)
+	}
+}
+
+type staticDB struct {
+	DSN  string `meta:"dsn,static,default=local"`
+	Pool int    `meta:"pool,default=4"`
+}
+
+type staticStore interface{ store() }
+
+type staticRedis struct {
+	Addr string `meta:"addr,static"`
+	DB   int    `meta:"db,optional"`
+}
+
+func (staticRedis) store() {}
+
+func init() {
+	MustRegisterType[staticStore, staticRedis]("static-redis")
+}
+
+type staticNestedConfig struct {
+	DB    *staticDB   `meta:"db,optional,omitnil"`
+	Store staticStore `meta:"store,optional"`
+}
+
+func TestStaticInNewAndPolymorphicStructs(t *testing.T) {
+	config, target := newTestConfig[staticNestedConfig](t, map[string]any{"store": map[string]any{"type": "static-redis", "addr": "a:6379"}},
+		WithStaticPolicy(StaticRestart))
+
+	// A struct allocated by the update keeps the defaults of its static fields
+	if err := config.Update(map[string]any{"db": map[string]any{"dsn": "remote", "pool": 8}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if target.DB == nil || target.DB.DSN != "local" || target.DB.Pool != 8 {
+		t.Fatalf("static field of new struct applied live: %+v", target.DB)
+	}
+
+	// Static fields of polymorphic values keep their values
+	if err := config.Update(map[string]any{"store": map[string]any{"type": "static-redis", "addr": "b:6379", "db": 1}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if store := target.Store.(staticRedis); store.Addr != "a:6379" || store.DB != 1 {
+		t.Fatalf("static field of polymorphic value applied live: %+v", store)
+	}
+
+	pending := changePaths(config.RestartRequired())
+	if pending != "db.dsn, store.addr" {
+		t.Fatalf("unexpected restart changes %s", pending)
+	}
+}
@@ -0,0 +1,56 @@
+package zcfg
+
+import (
+	"sort"
+	"time"
+)
+
+// ReloadStatus describes the hot reload state of a config
+type ReloadStatus struct {
+	File            string    `json:"file,omitempty"`             // Watched file
+	WatcherRunning  bool      `json:"watcher_running"`            // Whether the file watcher is running
+	Reloads         int       `json:"reloads"`                    // Number of successful reloads
+	Failures        int       `json:"failures"`                   // Number of failed reloads
+	LastAttempt     time.Time `json:"last_attempt,omitzero"`      // Time of the last reload attempt
+	LastSuccess     time.Time `json:"last_success,omitzero"`      // Time of the last successful reload
+	LastError       string    `json:"last_error,omitempty"`       // Error of the last reload attempt, empty if it succeeded
+	RestartRequired []string  `json:"restart_required,omitempty"` // Static fields changed by updates that take effect after a restart
+}
+
+// recordReload records the result of a reload attempt from source
+func (c *Config) recordReload(source string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.reload.File = source
+	c.reload.LastAttempt = now
+	if err != nil {
+		c.reload.Failures++
+		c.reload.LastError = err.Error()
+		return
+	}
+	c.reload.Reloads++
+	c.reload.LastSuccess = now
+	c.reload.LastError = ""
+}
+
+// ReloadStatus returns the hot reload state of the config
+func (c *Config) ReloadStatus() ReloadStatus {
+	c.mu.RLock()
+	status := c.reload
+	for path := range c.restart {
+		status.RestartRequired = append(status.RestartRequired, path)
+	}
+	c.mu.RUnlock()
+	sort.Strings(status.RestartRequired)
+
+	if status.File == "" {
+		status.File = c.file
+		if c.remote != nil {
+			status.File = c.remote.url
+		}
+	}
+	status.WatcherRunning = c.IsWatcherRunning()
+	return status
+}
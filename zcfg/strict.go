@@ -0,0 +1,148 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// UnknownKey describes a config key that no struct field consumed
+type UnknownKey struct {
+	Path       string    // Full key path, e.g. server.tiemout
+	Suggestion string    // Closest known field name, empty if none is close enough
+	Position   *Position // Position of the key in the config source, nil if unknown
+}
+
+// String returns the key path with its position and an optional "did you mean" hint
+func (k UnknownKey) String() string {
+	s := k.Path
+	if k.Position != nil {
+		s += " at " + k.Position.String()
+	}
+	if k.Suggestion != "" {
+		s += fmt.Sprintf(" (did you mean %s?)", k.Suggestion)
+	}
+	return s
+}
+
+// UnknownKeysError is returned in strict mode when the config contains unknown keys
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+// Error implements error
+func (e *UnknownKeysError) Error() string {
+	parts := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		parts[i] = k.String()
+	}
+	return fmt.Sprintf("unknown config keys: %s", strings.Join(parts, ", "))
+}
+
+// mapVisit records which keys of a map were consumed while mapping it onto a struct
+type mapVisit struct {
+	m      map[string]any // Keeps the map alive so its identity is not reused
+	path   string
+	keys   []string
+	used   map[string]bool
+	fields []string
+	index  keyIndex // Normalized keys, built on first lookup
+}
+
+// visit returns the visit record for map m, creating it on first use
+func (ctx *mapContext) visit(m map[string]any, path string) *mapVisit {
+	id := reflect.ValueOf(m).Pointer()
+	if v, exists := ctx.byMap[id]; exists {
+		return v
+	}
+
+	v := &mapVisit{
+		m:    m,
+		path: path,
+		used: make(map[string]bool),
+	}
+	for key := range m {
+		v.keys = append(v.keys, key)
+	}
+	sort.Strings(v.keys)
+
+	// Empty maps have no keys to report, nil maps would also share identity
+	if len(m) > 0 {
+		ctx.byMap[id] = v
+		ctx.visits = append(ctx.visits, v)
+	}
+	return v
+}
+
+// addField records a field name that was looked up in the map
+func (v *mapVisit) addField(name string) {
+	v.fields = append(v.fields, name)
+}
+
+// findKey finds the key matching field name, indexing the keys once per map
+func (v *mapVisit) findKey(fieldName string, option *Option) (string, bool) {
+	if v.index == nil {
+		if normalize := keyNormalizer(option); normalize != nil {
+			v.index = newKeyIndex(v.m, normalize)
+		}
+	}
+	return findKeyIndexed(v.m, fieldName, option, v.index)
+}
+
+// use marks key as consumed
+func (v *mapVisit) use(key string) {
+	v.used[key] = true
+}
+
+// unknownKeys returns all keys not consumed by any field, with suggestions
+func (ctx *mapContext) unknownKeys() []UnknownKey {
+	var result []UnknownKey
+	for _, v := range ctx.visits {
+		for _, key := range v.keys {
+			if v.used[key] {
+				continue
+			}
+			path := key
+			if v.path != "" {
+				path = v.path + "." + key
+			}
+			result = append(result, UnknownKey{
+				Path:       path,
+				Suggestion: suggestFieldName(key, v.fields),
+				Position:   ctx.keyPosition(v.path, key),
+			})
+		}
+	}
+	return result
+}
+
+// checkUnknownKeys returns an UnknownKeysError in strict mode, otherwise the unknown keys as warnings
+func (ctx *mapContext) checkUnknownKeys() ([]UnknownKey, error) {
+	unknown := ctx.unknownKeys()
+	if len(unknown) > 0 && ctx.option.Strict {
+		return unknown, &UnknownKeysError{Keys: unknown}
+	}
+	return unknown, nil
+}
+
+// suggestFieldName returns the known field name closest to key, or empty if none is close enough
+func suggestFieldName(key string, fields []string) string {
+	normalize := func(s string) string {
+		s = strings.ToLower(s)
+		s = strings.ReplaceAll(s, "_", "")
+		return strings.ReplaceAll(s, "-", "")
+	}
+
+	normalizedKey := normalize(key)
+	best, bestDistance := "", -1
+	for _, field := range fields {
+		distance := levenshtein(normalizedKey, normalize(field))
+		// Allow roughly one edit per three characters, at least two
+		limit := max(2, len(field)/3)
+		if distance <= limit && (bestDistance < 0 || distance < bestDistance) {
+			best, bestDistance = field, distance
+		}
+	}
+	return best
+}
@@ -0,0 +1,81 @@
+package zcfg
+
+import (
+	"errors"
+	"testing"
+)
+
+type strictServer struct {
+	Timeout int    `meta:"timeout,default=30"`
+	Host    string `meta:"host,default=localhost"`
+}
+
+type strictConfig struct {
+	Server    strictServer     `meta:"server"`
+	Upstreams []mapperUpstream `meta:"upstreams,optional"`
+	Name      string           `meta:"name,optional"`
+}
+
+func TestStrictUnknownKeys(t *testing.T) {
+	raw := map[string]any{
+		"server":    map[string]any{"tiemout": 5, "host": "a"},
+		"upstreams": []any{map[string]any{"host": "b", "wieght": 2}},
+		"nmae":      "x",
+		"zzz":       1,
+	}
+
+	_, err := loadTestConfig[strictConfig](raw, WithStrict(true))
+	var unknownErr *UnknownKeysError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("expected UnknownKeysError, got %v", err)
+	}
+
+	want := map[string]string{"server.tiemout": "timeout", "upstreams[0].wieght": "weight", "nmae": "name", "zzz": ""}
+	if len(unknownErr.Keys) != len(want) {
+		t.Fatalf("unexpected unknown keys: %v", unknownErr.Keys)
+	}
+	for _, key := range unknownErr.Keys {
+		suggestion, exists := want[key.Path]
+		if !exists || key.Suggestion != suggestion {
+			t.Fatalf("unexpected unknown key %+v", key)
+		}
+	}
+
+	// Without strict mode the keys are reported as warnings and the config loads
+	config, target := newTestConfig[strictConfig](t, raw)
+	if target.Server.Timeout != 30 || len(config.UnknownKeys()) != len(want) {
+		t.Fatalf("unexpected lenient load: %+v, unknown %v", target, config.UnknownKeys())
+	}
+}
+
+func TestStrictUpdate(t *testing.T) {
+	config, target := newTestConfig[strictConfig](t, map[string]any{"server": map[string]any{}}, WithStrict(true))
+	if len(config.UnknownKeys()) != 0 {
+		t.Fatalf("unexpected unknown keys: %v", config.UnknownKeys())
+	}
+
+	err := config.Update(map[string]any{"server": map[string]any{"timeout": 5, "hots": "b"}})
+	var unknownErr *UnknownKeysError
+	if !errors.As(err, &unknownErr) || unknownErr.Keys[0].Path != "server.hots" || unknownErr.Keys[0].Suggestion != "host" {
+		t.Fatalf("expected unknown key server.hots, got %v", err)
+	}
+	if target.Server.Timeout != 30 {
+		t.Fatal("update with unknown keys was applied")
+	}
+}
+
+func TestSuggestFieldName(t *testing.T) {
+	fields := []string{"max_conns", "timeout", "host"}
+	tests := map[string]string{
+		"maxConns": "max_conns",
+		"max-cons": "max_conns",
+		"timeot":   "timeout",
+		"region":   "",
+		"x":        "",
+	}
+	for key, want := range tests {
+		if got := suggestFieldName(key, fields); got != want {
+			t.Errorf("suggestFieldName(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package zcfg
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// renderTemplates renders string values containing {{ with text/template.
+// The template data is the config map itself, so values can refer to other keys, e.g. {{ .server.port }}.
+func renderTemplates(m map[string]any, option *Option, env *envLookup) (map[string]any, error) {
+	funcs := templateFuncs(env, option.TemplateDir)
+	for name, fn := range option.TemplateFuncs {
+		funcs[name] = fn
+	}
+
+	result, err := renderValue(m, "", m, funcs)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]any), nil
+}
+
+// renderValue renders templates in value recursively with path tracking
+func renderValue(value any, path string, data map[string]any, funcs template.FuncMap) (any, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		tmpl, err := template.New(path).Funcs(funcs).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("field %s template error: %w", path, err)
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("field %s template error: %w", path, err)
+		}
+		return b.String(), nil
+	case map[string]any, map[any]any:
+		m, _ := toStringMap(v)
+		result := make(map[string]any, len(m))
+		for key, item := range m {
+			rendered, err := renderValue(item, joinPath(path, key), data, funcs)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = rendered
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, fmt.Sprintf("%s[%d]", path, i), data, funcs)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	case []map[string]any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			rendered, err := renderValue(item, fmt.Sprintf("%s[%d]", path, i), data, funcs)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = rendered
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// templateFuncs returns the built-in template functions resolving environment variables with env
+// and reading files below dir
+func templateFuncs(env *envLookup, dir string) template.FuncMap {
+	return template.FuncMap{
+		// env returns an environment variable, or the optional default if it is unset
+		"env": func(name string, defaultValue ...string) string {
+			if value := env.lookup(name); value != "" {
+				return value
+			}
+			if len(defaultValue) > 0 {
+				return defaultValue[0]
+			}
+			return ""
+		},
+		// file returns the contents of a file below dir without the trailing newline
+		"file": func(path string) (string, error) {
+			data, err := readTemplateFile(dir, path)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimRight(string(data), "\r\n"), nil
+		},
+		"hostname": os.Hostname,
+		"b64enc": func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		},
+		"b64dec": func(s string) (string, error) {
+			data, err := base64.StdEncoding.DecodeString(s)
+			return string(data), err
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		// join joins the items of a list with sep
+		"join": func(sep string, list any) (string, error) {
+			items, ok := toSlice(list)
+			if !ok {
+				return "", fmt.Errorf("join expected list, got %T", list)
+			}
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = fmt.Sprintf("%v", item)
+			}
+			return strings.Join(parts, sep), nil
+		},
+		// default returns value, or defaultValue if value is empty
+		"default": func(defaultValue, value any) any {
+			if isZeroValue(value) {
+				return defaultValue
+			}
+			return value
+		},
+		"add": func(a, b any) (int64, error) {
+			return templateArith(a, b, func(x, y int64) (int64, error) { return x + y, nil })
+		},
+		"sub": func(a, b any) (int64, error) {
+			return templateArith(a, b, func(x, y int64) (int64, error) { return x - y, nil })
+		},
+		"mul": func(a, b any) (int64, error) {
+			return templateArith(a, b, func(x, y int64) (int64, error) { return x * y, nil })
+		},
+		"div": func(a, b any) (int64, error) {
+			return templateArith(a, b, func(x, y int64) (int64, error) {
+				if y == 0 {
+					return 0, errors.New("division by zero")
+				}
+				return x / y, nil
+			})
+		},
+		"mod": func(a, b any) (int64, error) {
+			return templateArith(a, b, func(x, y int64) (int64, error) {
+				if y == 0 {
+					return 0, errors.New("division by zero")
+				}
+				return x % y, nil
+			})
+		},
+	}
+}
+
+// templateArith converts a and b to integers and applies op
+func templateArith(a, b any, op func(x, y int64) (int64, error)) (int64, error) {
+	x, err := templateInt(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := templateInt(b)
+	if err != nil {
+		return 0, err
+	}
+	return op(x, y)
+}
+
+// templateInt converts numbers and numeric strings to int64
+func templateInt(value any) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float()), nil
+	case reflect.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(v.String()), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert '%v' to integer", value)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to integer", value)
+	}
+}
+
+// readTemplateFile reads path relative to dir, paths escaping dir are rejected
+func readTemplateFile(dir, path string) ([]byte, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file %s is not readable, no template dir is set", path)
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	f, err := root.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,37 @@
+package zcfg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateFileConfinedToDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	option := NewOption()
+	render := func(value string) (any, error) {
+		m, err := renderTemplates(map[string]any{"token": value}, option, nil)
+		if err != nil {
+			return nil, err
+		}
+		return m["token"], nil
+	}
+
+	if _, err := render(`{{ file "token" }}`); err == nil {
+		t.Fatal("file should be disabled without a template dir")
+	}
+
+	option.TemplateDir = dir
+	if value, err := render(`{{ file "token" }}`); err != nil || value != "s3cret" {
+		t.Fatalf("unexpected value %v, error %v", value, err)
+	}
+	for _, path := range []string{"../token", "/etc/hostname", filepath.Join(dir, "token")} {
+		if _, err := render(`{{ file "` + path + `" }}`); err == nil {
+			t.Fatalf("file %s outside the template dir was read", path)
+		}
+	}
+}
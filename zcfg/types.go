@@ -0,0 +1,180 @@
+package zcfg
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Global registry of concrete types for polymorphic (interface-typed) fields
+var (
+	typesMu       sync.RWMutex
+	typesRegistry = make(map[reflect.Type]map[string]reflect.Type)
+)
+
+// RegisterType registers concrete struct type T under name for interface-typed fields of type I.
+// When such a field is decoded, the discriminator key (Option.TypeKey, default "type")
+// selects the concrete type to instantiate. Either T or *T must implement I.
+func RegisterType[I any, T any](name string) error {
+	ifaceType := reflect.TypeOf((*I)(nil)).Elem()
+	if ifaceType.Kind() != reflect.Interface {
+		return fmt.Errorf("register type %s: %s is not an interface", name, ifaceType)
+	}
+
+	concreteType := reflect.TypeOf((*T)(nil)).Elem()
+	if concreteType.Kind() != reflect.Struct {
+		return fmt.Errorf("register type %s: %s is not a struct", name, concreteType)
+	}
+	if !concreteType.Implements(ifaceType) && !reflect.PointerTo(concreteType).Implements(ifaceType) {
+		return fmt.Errorf("register type %s: %s does not implement %s", name, concreteType, ifaceType)
+	}
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	types, exists := typesRegistry[ifaceType]
+	if !exists {
+		types = make(map[string]reflect.Type)
+		typesRegistry[ifaceType] = types
+	}
+	if existing, exists := types[name]; exists && existing != concreteType {
+		return fmt.Errorf("register type %s: already registered as %s for %s", name, existing, ifaceType)
+	}
+	types[name] = concreteType
+
+	return nil
+}
+
+// MustRegisterType registers concrete type T for interface I, panics on error
+func MustRegisterType[I any, T any](name string) {
+	if err := RegisterType[I, T](name); err != nil {
+		panic(err)
+	}
+}
+
+// UnregisterType removes the concrete type registered under name for interface I
+func UnregisterType[I any](name string) {
+	ifaceType := reflect.TypeOf((*I)(nil)).Elem()
+
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	if types, exists := typesRegistry[ifaceType]; exists {
+		delete(types, name)
+		if len(types) == 0 {
+			delete(typesRegistry, ifaceType)
+		}
+	}
+}
+
+// RegisteredTypes returns the sorted names registered for interface I
+func RegisteredTypes[I any]() []string {
+	return registeredTypeNames(reflect.TypeOf((*I)(nil)).Elem())
+}
+
+// isPolymorphicType checks if type is an interface with registered concrete types
+func isPolymorphicType(t reflect.Type) bool {
+	if t.Kind() != reflect.Interface {
+		return false
+	}
+
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	_, exists := typesRegistry[t]
+	return exists
+}
+
+// registeredTypeNames returns the sorted names registered for interface type
+func registeredTypeNames(ifaceType reflect.Type) []string {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	names := make([]string, 0, len(typesRegistry[ifaceType]))
+	for name := range typesRegistry[ifaceType] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupType finds the concrete type registered under name for interface type
+func lookupType(ifaceType reflect.Type, name string) (reflect.Type, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	concreteType, exists := typesRegistry[ifaceType][name]
+	return concreteType, exists
+}
+
+// registeredTypeName finds the name concrete type is registered under for interface type
+func registeredTypeName(ifaceType, concreteType reflect.Type) (string, bool) {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	for name, t := range typesRegistry[ifaceType] {
+		if t == concreteType {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// setPolymorphicValue instantiates the concrete type selected by the discriminator key and maps value into it
+func setPolymorphicValue(field reflect.Value, value any, ctx *mapContext, fieldPath string) error {
+	ifaceType := field.Type()
+
+	valueMap, ok := toStringMap(value)
+	if !ok {
+		return fmt.Errorf("field %s expected map for %s, got %T", fieldPath, ifaceType, value)
+	}
+
+	typeKey := ctx.option.typeKey()
+	key, exists := findKeyInMap(valueMap, typeKey, ctx.option)
+
+	// Merge patches without a different type update the current value in place
+	if ctx.mergePatch && !field.IsNil() {
+		current, _ := registeredTypeName(ifaceType, indirectType(field.Elem().Type()))
+		if !exists || fmt.Sprintf("%v", valueMap[key]) == current {
+			merged, err := mergeStructValue(field.Elem(), valueMap, ctx, fieldPath)
+			if err != nil {
+				return err
+			}
+			if exists {
+				ctx.visit(valueMap, fieldPath).use(key)
+			}
+			field.Set(merged)
+			return nil
+		}
+	}
+
+	if !exists {
+		return fmt.Errorf("field %s missing discriminator key '%s' for %s, registered types: %s",
+			fieldPath, typeKey, ifaceType, strings.Join(registeredTypeNames(ifaceType), ", "))
+	}
+	name := fmt.Sprintf("%v", valueMap[key])
+
+	concreteType, exists := lookupType(ifaceType, name)
+	if !exists {
+		return fmt.Errorf("field %s unknown %s '%s' for %s, registered types: %s",
+			fieldPath, typeKey, name, ifaceType, strings.Join(registeredTypeNames(ifaceType), ", "))
+	}
+
+	// Map and validate the concrete struct
+	instance := reflect.New(concreteType)
+	if err := mapToStructWithPath(valueMap, instance.Interface(), ctx, fieldPath, false, false); err != nil {
+		return err
+	}
+	ctx.visit(valueMap, fieldPath).use(key)
+
+	// Prefer the value type if it implements the interface, otherwise use the pointer
+	if concreteType.Implements(ifaceType) {
+		field.Set(instance.Elem())
+	} else {
+		field.Set(instance)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package zcfg
+
+import (
+	"strings"
+	"testing"
+)
+
+type pipelineStage interface {
+	Kind() string
+}
+
+type kafkaSource struct {
+	Topic   string `meta:"topic"`
+	Brokers int    `meta:"brokers,default=3,range=[1:10]"`
+}
+
+func (kafkaSource) Kind() string { return "kafka" }
+
+type fileSink struct {
+	Path string `meta:"path"`
+}
+
+func (*fileSink) Kind() string { return "file" }
+
+type pipelineConfig struct {
+	Stages []pipelineStage `meta:"stages"`
+	Output pipelineStage   `meta:"output,optional"`
+}
+
+func init() {
+	MustRegisterType[pipelineStage, kafkaSource]("kafka")
+	MustRegisterType[pipelineStage, fileSink]("file")
+}
+
+func TestPolymorphicTypes(t *testing.T) {
+	_, target := newTestConfig[pipelineConfig](t, map[string]any{
+		"stages": []any{
+			map[string]any{"type": "kafka", "topic": "events"},
+			map[string]any{"type": "file", "path": "/tmp/out"},
+		},
+		"output": map[string]any{"type": "file", "path": "/tmp/final"},
+	})
+
+	if len(target.Stages) != 2 {
+		t.Fatalf("unexpected stages: %+v", target.Stages)
+	}
+	// Value receivers are stored by value, pointer receivers as pointers
+	if source, ok := target.Stages[0].(kafkaSource); !ok || source != (kafkaSource{Topic: "events", Brokers: 3}) {
+		t.Fatalf("unexpected stage 0: %#v", target.Stages[0])
+	}
+	if sink, ok := target.Stages[1].(*fileSink); !ok || sink.Path != "/tmp/out" {
+		t.Fatalf("unexpected stage 1: %#v", target.Stages[1])
+	}
+	if sink, ok := target.Output.(*fileSink); !ok || sink.Path != "/tmp/final" {
+		t.Fatalf("unexpected output: %#v", target.Output)
+	}
+
+	if names := RegisteredTypes[pipelineStage](); strings.Join(names, ",") != "file,kafka" {
+		t.Fatalf("unexpected registered types: %v", names)
+	}
+}
+
+func TestPolymorphicTypeKey(t *testing.T) {
+	_, target := newTestConfig[pipelineConfig](t, map[string]any{
+		"stages": []any{map[string]any{"kind": "kafka", "topic": "events"}},
+	}, WithTypeKey("kind"))
+	if _, ok := target.Stages[0].(kafkaSource); !ok {
+		t.Fatalf("unexpected stage: %#v", target.Stages[0])
+	}
+}
+
+func TestPolymorphicTypeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		stage any
+		err   string
+	}{
+		{name: "unknown type", stage: map[string]any{"type": "s3"}, err: "unknown type 's3' for zcfg.pipelineStage, registered types: file, kafka"},
+		{name: "missing type", stage: map[string]any{"topic": "events"}, err: "stages[0] missing discriminator key 'type'"},
+		{name: "not a map", stage: "kafka", err: "stages[0] expected map"},
+		{name: "concrete validation", stage: map[string]any{"type": "kafka", "topic": "events", "brokers": 20}, err: "stages[0].brokers"},
+		{name: "concrete required", stage: map[string]any{"type": "file"}, err: "stages[0].path is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadTestConfig[pipelineConfig](map[string]any{"stages": []any{tt.stage}})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+type unimplementedStage struct{}
+
+func TestRegisterTypeErrors(t *testing.T) {
+	if err := RegisterType[kafkaSource, kafkaSource]("x"); err == nil || !strings.Contains(err.Error(), "is not an interface") {
+		t.Fatalf("expected interface error, got %v", err)
+	}
+	if err := RegisterType[pipelineStage, string]("x"); err == nil || !strings.Contains(err.Error(), "is not a struct") {
+		t.Fatalf("expected struct error, got %v", err)
+	}
+	if err := RegisterType[pipelineStage, unimplementedStage]("x"); err == nil || !strings.Contains(err.Error(), "does not implement") {
+		t.Fatalf("expected implement error, got %v", err)
+	}
+	if err := RegisterType[pipelineStage, fileSink]("kafka"); err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	// Registering the same type again is allowed
+	if err := RegisterType[pipelineStage, kafkaSource]("kafka"); err != nil {
+		t.Fatalf("re-register: %v", err)
+	}
+
+	if err := RegisterType[pipelineStage, fileSink]("file-copy"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	UnregisterType[pipelineStage]("file-copy")
+	if names := RegisteredTypes[pipelineStage](); strings.Join(names, ",") != "file,kafka" {
+		t.Fatalf("unregistered type is still listed: %v", names)
+	}
+}
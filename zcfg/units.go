@@ -0,0 +1,349 @@
+package zcfg
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ByteSize represents a size in bytes, decoded from values like "10MB", "512KiB" or "1.5G".
+// Decimal units (KB, MB, ...) are powers of 1000, binary units (KiB, MiB, ...) powers of 1024.
+type ByteSize int64
+
+// Byte size units
+const (
+	Byte ByteSize = 1
+
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+
+	KiB ByteSize = 1024 * Byte
+	MiB ByteSize = 1024 * KiB
+	GiB ByteSize = 1024 * MiB
+	TiB ByteSize = 1024 * GiB
+	PiB ByteSize = 1024 * TiB
+)
+
+// byteSizeUnits maps lower-cased unit suffixes to their size
+var byteSizeUnits = map[string]ByteSize{
+	"":    Byte,
+	"b":   Byte,
+	"k":   KB,
+	"kb":  KB,
+	"m":   MB,
+	"mb":  MB,
+	"g":   GB,
+	"gb":  GB,
+	"t":   TB,
+	"tb":  TB,
+	"p":   PB,
+	"pb":  PB,
+	"ki":  KiB,
+	"kib": KiB,
+	"mi":  MiB,
+	"mib": MiB,
+	"gi":  GiB,
+	"gib": GiB,
+	"ti":  TiB,
+	"tib": TiB,
+	"pi":  PiB,
+	"pib": PiB,
+}
+
+// byteSizeFormats lists units used by String, largest first, binary before decimal
+var byteSizeFormats = []struct {
+	size ByteSize
+	name string
+}{
+	{PiB, "PiB"}, {PB, "PB"},
+	{TiB, "TiB"}, {TB, "TB"},
+	{GiB, "GiB"}, {GB, "GB"},
+	{MiB, "MiB"}, {MB, "MB"},
+	{KiB, "KiB"}, {KB, "KB"},
+}
+
+// ParseByteSize parses a human-readable byte size such as "10MB", "512KiB" or "1.5G"
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("invalid byte size: empty string")
+	}
+
+	// Split number and unit
+	idx := strings.IndexFunc(str, func(r rune) bool {
+		return unicode.IsLetter(r)
+	})
+	numStr, unitStr := str, ""
+	if idx >= 0 {
+		numStr, unitStr = str[:idx], str[idx:]
+	}
+	numStr = strings.TrimSpace(numStr)
+
+	unit, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(unitStr))]
+	if !ok {
+		return 0, fmt.Errorf("invalid byte size unit %q in %q", unitStr, s)
+	}
+
+	// Integers are parsed exactly to avoid float rounding on large values
+	if n, err := strconv.ParseInt(numStr, 10, 64); err == nil {
+		if n != 0 && (n > math.MaxInt64/int64(unit) || n < math.MinInt64/int64(unit)) {
+			return 0, fmt.Errorf("byte size %q overflows int64", s)
+		}
+		return ByteSize(n) * unit, nil
+	}
+
+	f, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+	size := f * float64(unit)
+	if size > math.MaxInt64 || size < math.MinInt64 {
+		return 0, fmt.Errorf("byte size %q overflows int64", s)
+	}
+	return ByteSize(math.Round(size)), nil
+}
+
+// String returns the size using the largest unit that represents it exactly, e.g. "10MiB"
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteSizeFormats {
+		if b%u.size == 0 {
+			return strconv.FormatInt(int64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// Bytes returns the size as a plain number of bytes
+func (b ByteSize) Bytes() int64 {
+	return int64(b)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// Rate represents a number of events per time interval, decoded from values like "100/s" or "5000/min"
+type Rate struct {
+	Count float64       // Number of events
+	Per   time.Duration // Interval the events are counted over
+}
+
+// rateUnits maps rate interval suffixes to their duration
+var rateUnits = map[string]time.Duration{
+	"ns":     time.Nanosecond,
+	"us":     time.Microsecond,
+	"µs":     time.Microsecond,
+	"ms":     time.Millisecond,
+	"s":      time.Second,
+	"sec":    time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"min":    time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// rateFormats lists interval names used by String
+var rateFormats = []struct {
+	per  time.Duration
+	name string
+}{
+	{24 * time.Hour, "d"},
+	{time.Hour, "h"},
+	{time.Minute, "min"},
+	{time.Second, "s"},
+	{time.Millisecond, "ms"},
+	{time.Microsecond, "us"},
+	{time.Nanosecond, "ns"},
+}
+
+// ParseRate parses a rate such as "100/s", "5000/min" or "10/30s".
+// A plain number is interpreted as events per second.
+func ParseRate(s string) (Rate, error) {
+	str := strings.TrimSpace(s)
+	numStr, perStr, found := strings.Cut(str, "/")
+
+	count, err := strconv.ParseFloat(strings.TrimSpace(numStr), 64)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate: %q", s)
+	}
+	if !found {
+		return Rate{Count: count, Per: time.Second}, nil
+	}
+
+	perStr = strings.ToLower(strings.TrimSpace(perStr))
+	per, ok := rateUnits[perStr]
+	if !ok {
+		// Allow intervals with a multiplier such as "30s"
+		if per, err = time.ParseDuration(perStr); err != nil {
+			return Rate{}, fmt.Errorf("invalid rate interval %q in %q", perStr, s)
+		}
+	}
+	if per <= 0 {
+		return Rate{}, fmt.Errorf("rate interval must be positive: %q", s)
+	}
+
+	return Rate{Count: count, Per: per}, nil
+}
+
+// PerSecond returns the rate normalized to events per second
+func (r Rate) PerSecond() float64 {
+	if r.Per <= 0 {
+		return 0
+	}
+	return r.Count / r.Per.Seconds()
+}
+
+// Interval returns the average time between two events
+func (r Rate) Interval() time.Duration {
+	if r.Count <= 0 {
+		return 0
+	}
+	return time.Duration(float64(r.Per) / r.Count)
+}
+
+// IsZero reports whether the rate is unset
+func (r Rate) IsZero() bool {
+	return r.Count == 0 && r.Per == 0
+}
+
+// String returns the rate in its original unit, e.g. "5000/min"
+func (r Rate) String() string {
+	count := strconv.FormatFloat(r.Count, 'f', -1, 64)
+	for _, u := range rateFormats {
+		if r.Per == u.per {
+			return count + "/" + u.name
+		}
+	}
+	if r.Per <= 0 {
+		return count + "/s"
+	}
+	return count + "/" + r.Per.String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (r *Rate) UnmarshalText(text []byte) error {
+	rate, err := ParseRate(string(text))
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+var (
+	byteSizeType = reflect.TypeOf(ByteSize(0))
+	rateType     = reflect.TypeOf(Rate{})
+)
+
+// isUnitType checks if type is one of the unit types decoded from human-friendly strings
+func isUnitType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == byteSizeType || t == rateType
+}
+
+// parseByteSize parses byte size from string or numeric value (bytes)
+func parseByteSize(value any) (ByteSize, error) {
+	switch v := value.(type) {
+	case ByteSize:
+		return v, nil
+	case string:
+		return ParseByteSize(v)
+	case int, int8, int16, int32, int64:
+		return ByteSize(reflect.ValueOf(v).Int()), nil
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("byte size %d overflows int64", u)
+		}
+		return ByteSize(u), nil
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if f != math.Trunc(f) {
+			return 0, fmt.Errorf("byte size %v is not a whole number of bytes", f)
+		}
+		return ByteSize(f), nil
+	default:
+		return 0, fmt.Errorf("unsupported byte size type: %T", v)
+	}
+}
+
+// parseRate parses rate from string or numeric value (events per second)
+func parseRate(value any) (Rate, error) {
+	switch v := value.(type) {
+	case Rate:
+		return v, nil
+	case string:
+		return ParseRate(v)
+	case int, int8, int16, int32, int64:
+		return Rate{Count: float64(reflect.ValueOf(v).Int()), Per: time.Second}, nil
+	case uint, uint8, uint16, uint32, uint64:
+		return Rate{Count: float64(reflect.ValueOf(v).Uint()), Per: time.Second}, nil
+	case float32, float64:
+		return Rate{Count: reflect.ValueOf(v).Float(), Per: time.Second}, nil
+	default:
+		return Rate{}, fmt.Errorf("unsupported rate type: %T", v)
+	}
+}
+
+// decodeUnitValue converts raw value to the unit type of t, other types are returned unchanged
+func decodeUnitValue(t reflect.Type, value any) (any, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case byteSizeType:
+		return parseByteSize(value)
+	case rateType:
+		return parseRate(value)
+	default:
+		return value, nil
+	}
+}
+
+// parseRangeBound parses a range bound as plain number, byte size or rate
+func parseRangeBound(s string) (float64, error) {
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	if size, err := ParseByteSize(s); err == nil {
+		return float64(size), nil
+	}
+	if strings.Contains(s, "/") {
+		if rate, err := ParseRate(s); err == nil {
+			return rate.PerSecond(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid range bound: %s", s)
+}
@@ -1,11 +1,16 @@
 package zcfg
 
 import (
+	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
 	"unicode"
 )
 
+// snakeCaseRegex matches word boundaries in PascalCase and camelCase names
+var snakeCaseRegex = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
 // convertFieldName converts field name according to match mode
 func convertFieldName(fieldName string, mode MatchMode) string {
 	switch mode {
@@ -17,11 +22,29 @@ func convertFieldName(fieldName string, mode MatchMode) string {
 		return toCamelCase(fieldName)
 	case MatchSnakeCase:
 		return toSnakeCase(fieldName)
+	case MatchKebabCase:
+		return strings.ReplaceAll(toSnakeCase(fieldName), "_", "-")
+	case MatchScreamingSnakeCase:
+		return strings.ToUpper(toSnakeCase(strings.ReplaceAll(fieldName, "-", "_")))
+	case MatchFuzzy:
+		return fuzzyKey(fieldName)
 	default:
 		return strings.ToLower(fieldName)
 	}
 }
 
+// fieldKey returns the config key of a struct field, the tag name or the field name in the key
+// style of the match mode, e.g. port for an untagged Port field
+func fieldKey(fieldType reflect.StructField, tagInfo *TagInfo, option *Option) string {
+	if tagInfo.FieldName != "" {
+		return tagInfo.FieldName
+	}
+	if option.Matcher != nil {
+		return fieldType.Name
+	}
+	return convertFieldName(fieldType.Name, option.MatchMode)
+}
+
 // toCamelCase converts PascalCase to camelCase
 func toCamelCase(s string) string {
 	if len(s) == 0 {
@@ -39,39 +62,136 @@ func toSnakeCase(s string) string {
 	}
 
 	// Insert underscore before uppercase letters (except the first one)
-	re := regexp.MustCompile(`([a-z0-9])([A-Z])`)
-	snake := re.ReplaceAllString(s, `${1}_${2}`)
+	snake := snakeCaseRegex.ReplaceAllString(s, `${1}_${2}`)
 
 	// Convert to lowercase
 	return strings.ToLower(snake)
 }
 
-// findValueInMap finds value in map using different matching modes
-func findValueInMap(m map[string]any, fieldName string, mode MatchMode) (any, bool) {
-	// First try exact match
-	if value, exists := m[fieldName]; exists {
-		return value, true
+// fuzzyKey normalizes a name by lowercasing it and removing underscores and dashes
+func fuzzyKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// keyNormalizer returns the function normalizing keys for indexed matching,
+// nil if the mode only matches the converted field name exactly
+func keyNormalizer(option *Option) func(string) string {
+	if option.Matcher != nil {
+		return option.Matcher
 	}
+	switch option.MatchMode {
+	case MatchIgnoreCase:
+		return strings.ToLower
+	case MatchFuzzy:
+		return fuzzyKey
+	default:
+		return nil
+	}
+}
 
-	// Then try converted field name
-	convertedName := convertFieldName(fieldName, mode)
-	if value, exists := m[convertedName]; exists {
-		return value, true
+// keyIndex maps normalized keys to the keys of a map
+type keyIndex map[string]string
+
+// newKeyIndex indexes the keys of m by their normalized form, the smallest key wins on collisions
+func newKeyIndex(m map[string]any, normalize func(string) string) keyIndex {
+	index := make(keyIndex, len(m))
+	for key := range m {
+		normalized := normalize(key)
+		if existing, exists := index[normalized]; !exists || key < existing {
+			index[normalized] = key
+		}
 	}
+	return index
+}
 
-	// For case insensitive mode, try all keys
-	if mode == MatchIgnoreCase {
-		lowerFieldName := strings.ToLower(fieldName)
-		for key, value := range m {
-			if strings.ToLower(key) == lowerFieldName {
-				return value, true
-			}
+// isStructType checks if type is a struct or pointer to struct mapped from a nested map.
+// Value types like Rate are structs but decoded from scalars.
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && !isUnitType(t)
+}
+
+// indirectType returns the type pointed to by t, following all pointers
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// toStringMap converts map value to map[string]any.
+// YAML decodes maps with non-string keys as map[any]any, their keys are stringified.
+func toStringMap(value any) (map[string]any, bool) {
+	switch m := value.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		result := make(map[string]any, len(m))
+		for k, v := range m {
+			result[fmt.Sprintf("%v", k)] = v
 		}
+		return result, true
+	default:
+		return nil, false
 	}
+}
 
+// findValueInMap finds value in map using the option's matching mode
+func findValueInMap(m map[string]any, fieldName string, option *Option) (any, bool) {
+	if key, exists := findKeyInMap(m, fieldName, option); exists {
+		return m[key], true
+	}
 	return nil, false
 }
 
+// findKeyInMap finds the map key matching field name using the option's matching mode
+func findKeyInMap(m map[string]any, fieldName string, option *Option) (string, bool) {
+	return findKeyIndexed(m, fieldName, option, nil)
+}
+
+// findKeyIndexed finds the map key matching field name, using index for normalized
+// matching if given. Without an index the keys are scanned.
+func findKeyIndexed(m map[string]any, fieldName string, option *Option, index keyIndex) (string, bool) {
+	// First try exact match
+	if _, exists := m[fieldName]; exists {
+		return fieldName, true
+	}
+
+	// Then try converted field name
+	if option.Matcher == nil {
+		convertedName := convertFieldName(fieldName, option.MatchMode)
+		if _, exists := m[convertedName]; exists {
+			return convertedName, true
+		}
+	}
+
+	// Finally compare normalized names
+	normalize := keyNormalizer(option)
+	if normalize == nil {
+		return "", false
+	}
+	if index == nil {
+		index = newKeyIndex(m, normalize)
+	}
+	key, exists := index[normalize(fieldName)]
+	return key, exists
+}
+
+// joinPath joins a base path and a key with a dot
+func joinPath(basePath, key string) string {
+	if basePath == "" {
+		return key
+	}
+	return basePath + "." + key
+}
+
 // getNestedValue gets nested value from map using dot notation path
 func getNestedValue(m map[string]any, path string) (any, bool) {
 	if path == "" {
@@ -145,3 +265,133 @@ func isZeroValue(v any) bool {
 		return false
 	}
 }
+
+// cloneValue returns a deep copy of v, unexported struct fields are copied shallowly.
+// Shared pointers and maps stay shared in the copy, so pointer cycles are preserved.
+func cloneValue(v reflect.Value) reflect.Value {
+	return cloneValueSeen(v, make(map[clonedRef]reflect.Value))
+}
+
+// clonedRef identifies a pointer or map already copied by cloneValue
+type clonedRef struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// cloneValueSeen deep copies v, reusing the copies of pointers and maps in seen
+func cloneValueSeen(v reflect.Value, seen map[clonedRef]reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		ref := clonedRef{ptr: v.Pointer(), typ: v.Type()}
+		if clone, exists := seen[ref]; exists {
+			return clone
+		}
+		result := reflect.New(v.Type().Elem())
+		seen[ref] = result
+		result.Elem().Set(cloneValueSeen(v.Elem(), seen))
+		return result
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		result := reflect.New(v.Type()).Elem()
+		result.Set(cloneValueSeen(v.Elem(), seen))
+		return result
+
+	case reflect.Struct:
+		result := reflect.New(v.Type()).Elem()
+		result.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if result.Field(i).CanSet() {
+				result.Field(i).Set(cloneValueSeen(v.Field(i), seen))
+			}
+		}
+		return result
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		result := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result.Index(i).Set(cloneValueSeen(v.Index(i), seen))
+		}
+		return result
+
+	case reflect.Map:
+		if v.IsNil() {
+			return reflect.Zero(v.Type())
+		}
+		ref := clonedRef{ptr: v.Pointer(), typ: v.Type()}
+		if clone, exists := seen[ref]; exists {
+			return clone
+		}
+		result := reflect.MakeMapWithSize(v.Type(), v.Len())
+		seen[ref] = result
+		iter := v.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), cloneValueSeen(iter.Value(), seen))
+		}
+		return result
+
+	default:
+		result := reflect.New(v.Type()).Elem()
+		result.Set(v)
+		return result
+	}
+}
+
+// assignValue copies src into dst, reusing existing pointers to nested structs
+// so that references held by callers keep observing the current values
+func assignValue(dst, src reflect.Value) {
+	assignValueSeen(dst, src, make(map[clonedRef]bool))
+}
+
+// assignValueSeen copies src into dst, pointers in seen are already being assigned
+func assignValueSeen(dst, src reflect.Value, seen map[clonedRef]bool) {
+	switch {
+	case dst.Kind() == reflect.Struct && !isUnitType(dst.Type()):
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				assignValueSeen(dst.Field(i), src.Field(i), seen)
+			}
+		}
+	case dst.Kind() == reflect.Ptr && isStructType(dst.Type()) && !dst.IsNil() && !src.IsNil():
+		ref := clonedRef{ptr: dst.Pointer(), typ: dst.Type()}
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+		assignValueSeen(dst.Elem(), src.Elem(), seen)
+	default:
+		dst.Set(src)
+	}
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
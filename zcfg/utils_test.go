@@ -0,0 +1,112 @@
+package zcfg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConvertFieldName(t *testing.T) {
+	tests := map[MatchMode]string{
+		MatchNormal:             "MaxConns",
+		MatchIgnoreCase:         "maxconns",
+		MatchCamelCase:          "maxConns",
+		MatchSnakeCase:          "max_conns",
+		MatchKebabCase:          "max-conns",
+		MatchScreamingSnakeCase: "MAX_CONNS",
+		MatchFuzzy:              "maxconns",
+	}
+	for mode, want := range tests {
+		if got := convertFieldName("MaxConns", mode); got != want {
+			t.Errorf("convertFieldName(MaxConns, %d) = %s, want %s", mode, got, want)
+		}
+	}
+}
+
+type matchConfig struct {
+	MaxConns int
+	Name     string `meta:"name,optional"`
+}
+
+func TestMatchModes(t *testing.T) {
+	tests := []struct {
+		mode    MatchMode
+		key     string
+		matched bool
+	}{
+		{MatchNormal, "MaxConns", true},
+		{MatchNormal, "maxconns", false},
+		{MatchIgnoreCase, "MAXCONNS", true},
+		{MatchIgnoreCase, "max_conns", false},
+		{MatchCamelCase, "maxConns", true},
+		{MatchCamelCase, "max_conns", false},
+		{MatchSnakeCase, "max_conns", true},
+		{MatchSnakeCase, "max-conns", false},
+		{MatchKebabCase, "max-conns", true},
+		{MatchKebabCase, "max_conns", false},
+		{MatchScreamingSnakeCase, "MAX_CONNS", true},
+		{MatchScreamingSnakeCase, "max_conns", false},
+		{MatchFuzzy, "max_conns", true},
+		{MatchFuzzy, "maxConns", true},
+		{MatchFuzzy, "Max-Conns", true},
+		{MatchFuzzy, "max.conns", false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", tt.mode, tt.key), func(t *testing.T) {
+			config, err := loadTestConfig[matchConfig](map[string]any{tt.key: 5}, WithMatchMode(tt.mode))
+			if !tt.matched {
+				if err == nil {
+					t.Fatalf("mode %d matched key %s", tt.mode, tt.key)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mode %d: %v", tt.mode, err)
+			}
+			if target := config.GetTarget().(*matchConfig); target.MaxConns != 5 {
+				t.Fatalf("mode %d: unexpected value %d", tt.mode, target.MaxConns)
+			}
+		})
+	}
+}
+
+func TestCustomMatcher(t *testing.T) {
+	// Keys match fields ignoring a service prefix
+	matcher := func(name string) string {
+		return strings.TrimPrefix(strings.ToLower(name), "svc_")
+	}
+	_, target := newTestConfig[matchConfig](t, map[string]any{"SVC_MAXCONNS": 7, "svc_name": "a"}, WithMatcher(matcher))
+	if target.MaxConns != 7 || target.Name != "a" {
+		t.Fatalf("unexpected values: %+v", target)
+	}
+}
+
+func TestKeyIndex(t *testing.T) {
+	m := map[string]any{"Max_Conns": 1, "max-conns": 2, "maxConns": 3, "timeout": 4}
+	option := NewOption()
+	option.MatchMode = MatchFuzzy
+
+	// The smallest key wins when several keys normalize to the same name
+	index := newKeyIndex(m, keyNormalizer(option))
+	if len(index) != 2 || index["maxconns"] != "Max_Conns" || index["timeout"] != "timeout" {
+		t.Fatalf("unexpected index: %v", index)
+	}
+
+	// Indexed lookups find the same keys as scans
+	for _, name := range []string{"MaxConns", "max_conns", "Timeout", "missing"} {
+		indexedKey, indexedFound := findKeyIndexed(m, name, option, index)
+		scannedKey, scannedFound := findKeyInMap(m, name, option)
+		if indexedKey != scannedKey || indexedFound != scannedFound {
+			t.Fatalf("lookup of %s: indexed %q %v, scanned %q %v", name, indexedKey, indexedFound, scannedKey, scannedFound)
+		}
+	}
+
+	// Exact keys take precedence over normalized matches
+	if key, _ := findKeyIndexed(m, "maxConns", option, index); key != "maxConns" {
+		t.Fatalf("expected exact key maxConns, got %s", key)
+	}
+	option.MatchMode = MatchSnakeCase
+	if keyNormalizer(option) != nil {
+		t.Fatal("snake case mode should only match converted names")
+	}
+}
@@ -11,14 +11,29 @@ import (
 
 // TagInfo represents parsed tag information
 type TagInfo struct {
-	FieldName string   // Custom field name
-	Default   string   // Default value
-	Options   []string // Valid options
-	RangeMin  *float64 // Range minimum
-	RangeMax  *float64 // Range maximum
-	Watch     bool     // Whether to watch for changes
-	Optional  bool     // Whether field is optional
-	Skip      bool     // Whether to skip this field
+	FieldName       string   // Custom field name
+	Default         string   // Default value
+	Options         []string // Valid options
+	RangeMin        *float64 // Range minimum
+	RangeMax        *float64 // Range maximum
+	Watch           bool     // Whether to watch for changes
+	Optional        bool     // Whether field is optional
+	Skip            bool     // Whether to skip this field
+	Aliases         []string // Deprecated alternative names
+	Deprecated      bool     // Whether the field itself is deprecated
+	DeprecationNote string   // Optional hint shown with deprecation warnings
+	Secret          bool     // Whether the value is sensitive and must be masked in output
+	OmitNil         bool     // Whether an optional pointer stays nil when its key is missing
+	Static          bool     // Whether changes require a restart, see StaticPolicy
+}
+
+// fieldTag returns the tag of field in struct type t. Built-in config types like FlagRule
+// are always tagged with meta, whatever tag name the option sets.
+func fieldTag(t reflect.Type, field reflect.StructField, option *Option) string {
+	if t == flagRuleType || t == flagConditionType {
+		return field.Tag.Get("meta")
+	}
+	return field.Tag.Get(option.TagName)
 }
 
 // parseTag parses struct tag and returns TagInfo
@@ -57,17 +72,31 @@ func parseTag(tag string) *TagInfo {
 			info.Options = strings.Split(optionsStr, "|")
 		case strings.HasPrefix(part, "range="):
 			parseRange(strings.TrimPrefix(part, "range="), info)
+		case strings.HasPrefix(part, "alias="):
+			info.Aliases = strings.Split(strings.TrimPrefix(part, "alias="), "|")
+		case part == "deprecated":
+			info.Deprecated = true
+		case strings.HasPrefix(part, "deprecated="):
+			info.Deprecated = true
+			info.DeprecationNote = strings.TrimPrefix(part, "deprecated=")
 		case part == "watch":
 			info.Watch = true
 		case part == "optional":
 			info.Optional = true
+		case part == "secret":
+			info.Secret = true
+		case part == "omitnil":
+			info.OmitNil = true
+		case part == "static":
+			info.Static = true
 		}
 	}
 
 	return info
 }
 
-// parseRange parses range specification like [0:100], (0:100], [0:100), (0:100)
+// parseRange parses range specification like [0:100], (0:100], [0:100), (0:100).
+// Bounds may use byte size or rate units, e.g. [1KB:10MiB] or [1/s:100/s]
 func parseRange(rangeStr string, info *TagInfo) {
 	rangeRegex := regexp.MustCompile(`^([\[\(])([^:]*):([^\]\)]*)([\]\)])$`)
 	matches := rangeRegex.FindStringSubmatch(rangeStr)
@@ -80,14 +109,14 @@ func parseRange(rangeStr string, info *TagInfo) {
 
 	// Parse min value
 	if minStr != "" {
-		if fMin, err := strconv.ParseFloat(minStr, 64); err == nil {
+		if fMin, err := parseRangeBound(minStr); err == nil {
 			info.RangeMin = &fMin
 		}
 	}
 
 	// Parse max value
 	if maxStr != "" {
-		if fMax, err := strconv.ParseFloat(maxStr, 64); err == nil {
+		if fMax, err := parseRangeBound(maxStr); err == nil {
 			info.RangeMax = &fMax
 		}
 	}
@@ -101,10 +130,9 @@ func validateValue(value any, tagInfo *TagInfo, fieldPath string) error {
 
 	// Validate options
 	if len(tagInfo.Options) > 0 {
-		valueStr := fmt.Sprintf("%v", value)
 		valid := false
 		for _, option := range tagInfo.Options {
-			if valueStr == option {
+			if matchesOption(value, option) {
 				valid = true
 				break
 			}
@@ -124,6 +152,20 @@ func validateValue(value any, tagInfo *TagInfo, fieldPath string) error {
 	return nil
 }
 
+// matchesOption checks if value equals option, unit values are compared by value so 1000KB matches 1MB
+func matchesOption(value any, option string) bool {
+	switch v := value.(type) {
+	case ByteSize:
+		size, err := ParseByteSize(option)
+		return err == nil && size == v
+	case Rate:
+		rate, err := ParseRate(option)
+		return err == nil && rate.PerSecond() == v.PerSecond()
+	default:
+		return fmt.Sprintf("%v", value) == option
+	}
+}
+
 // validateRange validates numeric range
 func validateRange(value any, tagInfo *TagInfo, fieldPath string) error {
 	var numValue float64
@@ -154,6 +196,10 @@ func validateRange(value any, tagInfo *TagInfo, fieldPath string) error {
 		numValue = float64(v)
 	case float64:
 		numValue = v
+	case ByteSize:
+		numValue = float64(v)
+	case Rate:
+		numValue = v.PerSecond()
 	case string:
 		numValue, err = strconv.ParseFloat(v, 64)
 		if err != nil {
@@ -0,0 +1,23 @@
+package zcfg
+
+import "testing"
+
+func TestUnitOptionsCompareValues(t *testing.T) {
+	type config struct {
+		Buffer ByteSize `meta:"buffer,options=1000KB|2MB"`
+		Limit  Rate     `meta:"limit,options=60/min|10/s"`
+	}
+
+	cfg, err := LoadFromJson[config]([]byte(`{"buffer":"1000KB","limit":"1/s"}`), WithUseEnv(false), WithRegistry(NewRegistry()))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Buffer != MB || cfg.Limit.PerSecond() != 1 {
+		t.Fatalf("unexpected values: %v %v", cfg.Buffer, cfg.Limit)
+	}
+
+	_, err = LoadFromJson[config]([]byte(`{"buffer":"3MB","limit":"1/s"}`), WithUseEnv(false), WithRegistry(NewRegistry()))
+	if err == nil {
+		t.Fatal("expected 3MB to be rejected")
+	}
+}
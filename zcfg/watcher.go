@@ -1,6 +1,7 @@
 package zcfg
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 type FileWatcher struct {
 	watcher  *fsnotify.Watcher
 	filePath string
+	paths    []string
 	config   *Config
 	stopCh   chan struct{}
 	running  bool
@@ -24,9 +26,16 @@ func NewFileWatcher(c *Config) (*FileWatcher, error) {
 		return nil, err
 	}
 
+	paths := c.watchPaths()
+	if len(paths) == 0 {
+		watcher.Close()
+		return nil, fmt.Errorf("no config files to watch")
+	}
+
 	fw := &FileWatcher{
 		watcher:  watcher,
-		filePath: c.file,
+		filePath: paths[0],
+		paths:    paths,
 		config:   c,
 		stopCh:   make(chan struct{}),
 		running:  false,
@@ -35,7 +44,7 @@ func NewFileWatcher(c *Config) (*FileWatcher, error) {
 	return fw, nil
 }
 
-// Start starts watching the file
+// Start starts watching the config file and overlays
 func (fw *FileWatcher) Start() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
@@ -44,9 +53,10 @@ func (fw *FileWatcher) Start() error {
 		return nil
 	}
 
-	err := fw.watcher.Add(fw.filePath)
-	if err != nil {
-		return err
+	for _, path := range fw.paths {
+		if err := fw.watcher.Add(path); err != nil {
+			return err
+		}
 	}
 
 	fw.running = true
@@ -109,7 +119,7 @@ func (fw *FileWatcher) watchLoop() {
 				return
 			}
 			// Log error but continue watching
-			_ = err // TODO: Add proper logging
+			fw.config.option.logger().Error().Err(err).Str("file", fw.filePath).Msg("config watcher error")
 
 		case <-fw.stopCh:
 			return
@@ -117,18 +127,41 @@ func (fw *FileWatcher) watchLoop() {
 	}
 }
 
-// reloadConfig reloads configuration from file
+// reloadConfig reloads configuration from file, overlays and env files
 func (fw *FileWatcher) reloadConfig() {
-	// Parse the updated config file
-	newRawMap, err := parseConfigFile(fw.filePath)
+	// Parse the updated env files, config file and overlays
+	var newRawMap map[string]any
+	var positions positionIndex
+	env, err := loadEnvFiles(fw.config.option)
+	if err == nil {
+		newRawMap, positions, err = fw.config.readSources(env)
+	}
+	if err == nil {
+		// Update the config
+		source := Provenance{Kind: SourceFile, File: fw.filePath, Time: time.Now()}
+		err = fw.config.applyReload(newRawMap, positions, env, source)
+	}
+
+	fw.config.recordReload(fw.filePath, err)
 	if err != nil {
-		// TODO: Add proper error handling/logging
-		return
+		fw.config.option.logger().Error().Err(err).Str("file", fw.filePath).Msg("config reload failed")
 	}
+}
 
-	// Update the config
-	if err := fw.config.Update(newRawMap); err != nil {
-		// TODO: Add proper error handling/logging
-		return
+// applyReload applies m with the reloaded env files, keeping the previous env files if it fails
+func (c *Config) applyReload(m map[string]any, positions positionIndex, env *envLookup, source Provenance) error {
+	if !c.option.Updatable {
+		return fmt.Errorf("config is not updatable")
 	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.env
+	c.env = env
+	if err := c.apply(m, positions, source, false); err != nil {
+		c.env = previous
+		return err
+	}
+	return nil
 }